@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
 	"time"
 
 	optimization "github.com/greyskp/cost_optimization/optimizer"
@@ -19,10 +20,17 @@ func (PrintObserver) Observe(s optimization.Stats) {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		os.Exit(runReplay(os.Args[2:]))
+	}
+
+	latencies := optimization.NewHistogramObserver()
+	observer := optimization.MultiObserver(PrintObserver{}, latencies)
+
 	costs := []float64{
 		-10.0, 20.9, 15.7, 12.4, -40.0, 40.2, 4.7, 60.8, 12.3, -7.6,
 		27.6, 4.1, 18.9, 22.7, 31.4, 15.6, 6.2, 29.8, 24.5, 8.9}
-	optimized, err := optimization.CostOptimization(costs, optimization.WithObserver(PrintObserver{}))
+	optimized, err := optimization.CostOptimization(costs, optimization.WithObserver(observer))
 
 	if err != nil {
 		log.Fatalf("Error detected in CostOptimization: %v", err)
@@ -50,7 +58,7 @@ func main() {
 		43.9, 11.6, 16.8, -30.4, 24.1, -7.8, 28.9, -21.7, 9.4, 39.5,
 		34.2, -14.9, 6.7, -27.1, 19.8, -10.5, 36.4, -18.3}
 
-	optimizedLarge, err := optimization.CostOptimization(costsLarge, optimization.WithObserver(PrintObserver{}))
+	optimizedLarge, err := optimization.CostOptimization(costsLarge, optimization.WithObserver(observer))
 
 	if err != nil {
 		log.Fatalf("Error detected in CostOptimization: %v", err)
@@ -71,7 +79,7 @@ func main() {
 		-21.4, -8.6, -33.9, -7.2, -14.8, -26.5, -40.1, -11.3, -9.7, -35.8,
 		-27.6, -4.1, -18.9, -22.7, -31.4, -15.6, -6.2, -29.8, -24.5, -8.9}
 
-	optimizedNegatives, err := optimization.CostOptimization(costsNegatives, optimization.WithObserver(PrintObserver{}))
+	optimizedNegatives, err := optimization.CostOptimization(costsNegatives, optimization.WithObserver(observer))
 
 	if err != nil {
 		log.Fatalf("Error detected in CostOptimization: %v", err)
@@ -92,7 +100,7 @@ func main() {
 		21.4, 8.6, 33.9, 7.2, 14.8, 26.5, 40.1, 11.3, 9.7, 35.8,
 		27.6, 4.1, 18.9, 22.7, 31.4, 15.6, 6.2, 29.8, 24.5, 8.9}
 
-	optimizedPositives, err := optimization.CostOptimization(costsPositives, optimization.WithObserver(PrintObserver{}))
+	optimizedPositives, err := optimization.CostOptimization(costsPositives, optimization.WithObserver(observer))
 
 	if err != nil {
 		log.Fatalf("Error detected in CostOptimization: %v", err)
@@ -106,5 +114,13 @@ func main() {
 	}
 
 	fmt.Println(totalPositives)
+	fmt.Println("===================")
+
+	d := latencies.Snapshot().Duration
+	fmt.Printf(
+		"calls=%d min=%s mean=%s p50=%s p95=%s p99=%s max=%s\n",
+		d.Count, time.Duration(d.Min), time.Duration(d.Mean), time.Duration(d.P50),
+		time.Duration(d.P95), time.Duration(d.P99), time.Duration(d.Max),
+	)
 
 }
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	optimization "github.com/greyskp/cost_optimization/optimizer"
+)
+
+// runReplay re-runs the calls captured in a recording file and prints any divergence.
+// It returns the process exit code: 0 when everything matches, 1 on divergence, 2 on error.
+func runReplay(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: cmd replay <recording file>")
+		return 2
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening recording: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+
+	report, err := optimization.Replay(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error replaying recording: %v\n", err)
+		return 2
+	}
+
+	for _, d := range report.Divergences {
+		if d.RecordedErr != d.ReplayedErr {
+			fmt.Printf("record %d: recorded error %q, replayed error %q\n", d.Record, d.RecordedErr, d.ReplayedErr)
+		}
+		if len(d.Indices) > 0 {
+			fmt.Printf("record %d: %d indices differ: %v\n", d.Record, len(d.Indices), d.Indices)
+		}
+	}
+	fmt.Printf("replayed %d records, %d diverged\n", report.Records, len(report.Divergences))
+
+	if len(report.Divergences) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,177 @@
+package optimization
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+const defaultSampleSize = 4096
+
+// approxConfidence is the number of standard deviations added to the sampled quantile so that the
+// threshold pass almost always covers the required count.
+const approxConfidence = 3.0
+
+// ApproxResult is the output of CostOptimizationApprox. It is a separate type from the []int of the
+// exact API so an approximate selection cannot be mistaken for an exact one.
+type ApproxResult struct {
+	Selection []int
+	// Exact is true when Selection is identical to what CostOptimization would return.
+	Exact bool
+	// Threshold is the estimated cutoff: every non-negative cost at or below it is selected.
+	Threshold float64
+	// Extra is the number of costs selected beyond ⌈n/2⌉ because of the estimate.
+	Extra int
+	// GapBound is an upper bound on TotalCost(Selection) minus the optimal total cost.
+	GapBound float64
+}
+
+// CostOptimizationApprox trades optimality for speed on massive inputs. The cutoff cost is
+// estimated from a random sample of the input (see WithSampleSize and WithSeed) and all costs up to
+// it are selected in a single pass. The selection always satisfies the ⌈n/2⌉ constraint: if the
+// estimate falls short, the missing costs are filled exactly, which makes the result exact.
+// The extra costs selected are all within the threshold, so GapBound = Extra × Threshold.
+func CostOptimizationApprox(prices []float64, opts ...Option) (_ ApproxResult, err error) {
+	cfg := applyOptions(opts)
+	track := cfg.beginCall("optimization.CostOptimizationApprox", len(prices))
+	stats := Stats{N: len(prices)}
+	defer track.finish(&stats, &err)
+
+	if err = cfg.supports(0); err != nil {
+		return ApproxResult{}, err
+	}
+
+	if len(prices) == 0 {
+		return ApproxResult{}, ErrEmptyInput
+	}
+
+	minSize := requiredCount(len(prices))
+	res := make([]int, len(prices))
+
+	// The totals are only worth computing when someone is listening.
+	if _, silent := cfg.observer.(NoOpObserver); !silent {
+		defer func() {
+			if err == nil {
+				stats.CutoffCost, stats.TotalCost = selectionTotals(prices, res)
+			}
+		}()
+	}
+
+	// Validation and marking the negatives share a single pass.
+	for i, value := range prices {
+		if math.IsNaN(value) {
+			stats.ValidateDuration = track.lap(PhaseValidate)
+			return ApproxResult{}, ErrInvalidNumber
+		}
+		if i > 0 && i%progressInterval == 0 {
+			track.progress(PhaseValidate, i, len(prices))
+		}
+		if value < 0 {
+			res[i] = 1
+			stats.Negatives++
+		}
+	}
+	stats.SelectedCount = stats.Negatives
+	stats.ValidateDuration = track.lap(PhaseValidate)
+
+	if stats.SelectedCount >= minSize {
+		stats.Path = PathNegatives
+		return ApproxResult{Selection: res, Exact: true}, nil
+	}
+
+	stats.Path = PathApprox
+	stats.LeftToFill = minSize - stats.SelectedCount
+	nonNegatives := len(prices) - stats.SelectedCount
+	threshold := estimateThreshold(prices, stats.LeftToFill, nonNegatives, cfg)
+
+	for i, value := range prices {
+		if value >= 0 && value <= threshold {
+			res[i] = 1
+			stats.SelectedCount++
+		}
+	}
+
+	if stats.SelectedCount < minSize {
+		// The estimate was too low: fill the rest with the smallest costs above it, which
+		// yields exactly the k smallest non-negative costs.
+		stats.Replacements = fillSmallest(prices, res, minSize-stats.SelectedCount, nil)
+		stats.SelectedCount = minSize
+		stats.FillDuration = track.lap(PhaseFill)
+		return ApproxResult{Selection: res, Exact: true, Threshold: threshold}, nil
+	}
+
+	stats.FillDuration = track.lap(PhaseFill)
+
+	extra := stats.SelectedCount - minSize
+	result := ApproxResult{
+		Selection: res,
+		Exact:     extra == 0,
+		Threshold: threshold,
+		Extra:     extra,
+	}
+	if extra > 0 {
+		result.GapBound = float64(extra) * threshold
+	}
+	return result, nil
+}
+
+// estimateThreshold returns a cost that is likely to have at least k non-negative costs at or below it.
+func estimateThreshold(prices []float64, k, nonNegatives int, cfg options) float64 {
+	size := cfg.sampleSize
+	if size <= 0 {
+		size = defaultSampleSize
+	}
+
+	var sample []float64
+	margin := 0.0
+	if nonNegatives <= size {
+		// Small enough to look at every candidate: the quantile is exact.
+		sample = make([]float64, 0, nonNegatives)
+		for _, value := range prices {
+			if value >= 0 {
+				sample = append(sample, value)
+			}
+		}
+	} else {
+		rng := rand.New(rand.NewPCG(cfg.seed, cfg.seed^0x9e3779b97f4a7c15))
+		sample = make([]float64, 0, size)
+		for len(sample) < size {
+			if value := prices[rng.IntN(len(prices))]; value >= 0 {
+				sample = append(sample, value)
+			}
+		}
+		p := float64(k) / float64(nonNegatives)
+		margin = approxConfidence * math.Sqrt(p*(1-p)/float64(size))
+	}
+
+	slices.Sort(sample)
+	q := min(float64(k)/float64(nonNegatives)+margin, 1)
+	rank := min(max(int(math.Ceil(q*float64(len(sample))))-1, 0), len(sample)-1)
+	return sample[rank]
+}
+
+// fillSmallest marks the k smallest unselected costs of prices, lower indices first, and returns
+// the number of heap replacements. A non-nil progress is told how many costs were scanned after
+// every progressInterval of them.
+func fillSmallest(prices []float64, res []int, k int, progress func(done int)) int {
+	replacements := 0
+	smallest := NewBoundedTopK(k, lessCost)
+	for start := 0; start < len(prices); start += progressInterval {
+		if start > 0 && progress != nil {
+			progress(start)
+		}
+		for index := start; index < min(start+progressInterval, len(prices)); index++ {
+			if res[index] == 1 {
+				continue
+			}
+			full := smallest.Full()
+			if smallest.Offer(cost{prices[index], index}) && full {
+				replacements++
+			}
+		}
+	}
+	for _, v := range smallest.Items() {
+		res[v.index] = 1
+	}
+	return replacements
+}
@@ -0,0 +1,68 @@
+package optimization
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestApproxBound(t *testing.T) {
+	inputs := [][]float64{
+		{-10, 17, 15, -40, 20},
+		{-1, -2, -3, 4},
+		randFloats(-100.0, 500.0, 1001),
+		randFloats(0.0, 500.0, 200000),
+	}
+
+	for _, costs := range inputs {
+		exact, err := CostOptimization(costs)
+		if err != nil {
+			t.Fatalf("CostOptimization returned unexpected error: %v", err)
+		}
+		optimal, _ := TotalCost(costs, exact)
+
+		for _, sampleSize := range []int{1, 64, 1024} {
+			for seed := range uint64(5) {
+				result, err := CostOptimizationApprox(costs, WithSampleSize(sampleSize), WithSeed(seed))
+				if err != nil {
+					t.Fatalf("CostOptimizationApprox returned unexpected error: %v", err)
+				}
+
+				selected := countOnes(result.Selection)
+				if selected < len(costs)/2+len(costs)%2 {
+					t.Fatalf("not enough costs were selected: %d of %d", selected, len(costs))
+				}
+				total, _ := TotalCost(costs, result.Selection)
+				if gap := total - optimal; gap > result.GapBound+1e-6*max(1, -optimal, optimal) {
+					t.Fatalf("gap %v exceeds the reported bound %v", gap, result.GapBound)
+				}
+				if result.Exact != slices.Equal(result.Selection, exact) {
+					t.Fatalf("Exact flag is %v but selection equality is %v", result.Exact, !result.Exact)
+				}
+			}
+		}
+	}
+}
+
+func TestApproxPath(t *testing.T) {
+	var obs statsRecorder
+	costs := randFloats(0.0, 500.0, 50000)
+	result, err := CostOptimizationApprox(costs, WithObserver(&obs))
+	if err != nil {
+		t.Fatalf("CostOptimizationApprox returned unexpected error: %v", err)
+	}
+	if obs.last.Path != PathApprox {
+		t.Fatalf("CostOptimizationApprox took path %q, expected %q", obs.last.Path, PathApprox)
+	}
+	if result.Exact && result.Extra != 0 {
+		t.Fatalf("exact result reports %d extra selections", result.Extra)
+	}
+	if cutoff, total := selectionTotals(costs, result.Selection); obs.last.CutoffCost != cutoff || obs.last.TotalCost != total || cutoff == 0 {
+		t.Fatalf("got cutoff=%v total=%v, expected %v and %v", obs.last.CutoffCost, obs.last.TotalCost, cutoff, total)
+	}
+}
+
+func TestApproxErrors(t *testing.T) {
+	if _, err := CostOptimizationApprox([]float64{}); err != ErrEmptyInput {
+		t.Fatalf("empty input got %v, expected %v", err, ErrEmptyInput)
+	}
+}
@@ -0,0 +1,123 @@
+package optimization
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+const defaultAsyncBufferSize = 1024
+
+// AsyncObserver moves another Observer off the caller's goroutine. Stats are handed to a bounded
+// buffer drained by a background goroutine; when the buffer is full they are dropped and counted
+// rather than blocking CostOptimization. Panics raised by the wrapped observer are recovered and
+// counted. Lifecycle events are not forwarded, since they only make sense synchronously.
+type AsyncObserver struct {
+	next   Observer
+	events chan Stats
+
+	dropped atomic.Uint64
+	panics  atomic.Uint64
+
+	// closed is guarded by mu so that Observe never sends on a closed channel.
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+
+	// queued counts Stats offered to the buffer without serializing producers. It is incremented
+	// before the send, so a Stats accepted before Flush is always part of its target. handled
+	// counts those delivered or dropped and is guarded by progress so that Flush can wait on
+	// delivered.
+	queued    atomic.Uint64
+	progress  sync.Mutex
+	delivered *sync.Cond
+	handled   uint64
+}
+
+// NewAsyncObserver starts a background goroutine delivering Stats to next, buffering up to size of
+// them (1024 if size <= 0). Close must be called to stop it.
+func NewAsyncObserver(next Observer, size int) *AsyncObserver {
+	if size <= 0 {
+		size = defaultAsyncBufferSize
+	}
+	a := &AsyncObserver{
+		next:   next,
+		events: make(chan Stats, size),
+		done:   make(chan struct{}),
+	}
+	a.delivered = sync.NewCond(&a.progress)
+	go a.run()
+	return a
+}
+
+// Observe queues stats without blocking. Stats are dropped when the buffer is full or the
+// observer is closed.
+func (a *AsyncObserver) Observe(stats Stats) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		a.dropped.Add(1)
+		return
+	}
+
+	a.queued.Add(1)
+	select {
+	case a.events <- stats:
+	default:
+		a.dropped.Add(1)
+		a.settle()
+	}
+}
+
+// Dropped returns how many Stats were discarded.
+func (a *AsyncObserver) Dropped() uint64 { return a.dropped.Load() }
+
+// Panics returns how many calls to the wrapped observer panicked.
+func (a *AsyncObserver) Panics() uint64 { return a.panics.Load() }
+
+// Flush blocks until every Stats accepted before the call has been delivered.
+func (a *AsyncObserver) Flush() {
+	target := a.queued.Load()
+	a.progress.Lock()
+	defer a.progress.Unlock()
+	for a.handled < target {
+		a.delivered.Wait()
+	}
+}
+
+// Close delivers the Stats still buffered and stops the background goroutine. Stats observed
+// afterwards are dropped. It is safe to call Close more than once.
+func (a *AsyncObserver) Close() {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.events)
+	}
+	a.mu.Unlock()
+	<-a.done
+}
+
+func (a *AsyncObserver) run() {
+	defer close(a.done)
+	for stats := range a.events {
+		a.deliver(stats)
+		a.settle()
+	}
+}
+
+// settle records that one queued Stats has been delivered or dropped.
+func (a *AsyncObserver) settle() {
+	a.progress.Lock()
+	a.handled++
+	a.delivered.Broadcast()
+	a.progress.Unlock()
+}
+
+func (a *AsyncObserver) deliver(stats Stats) {
+	defer func() {
+		if recover() != nil {
+			a.panics.Add(1)
+		}
+	}()
+	a.next.Observe(stats)
+}
@@ -0,0 +1,109 @@
+package optimization
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAsyncObserverDelivers(t *testing.T) {
+	var count atomic.Int64
+	a := NewAsyncObserver(ObserverFunc(func(s Stats) { count.Add(int64(s.N)) }), 0)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				CostOptimization([]float64{3, 1, 2}, WithObserver(a))
+			}
+		}()
+	}
+	wg.Wait()
+	a.Flush()
+
+	if got := count.Load() + 3*int64(a.Dropped()); got != 8*100*3 {
+		t.Fatalf("delivered and dropped add up to %d, expected %d", got, 8*100*3)
+	}
+	a.Close()
+	a.Close()
+}
+
+func TestAsyncObserverFlush(t *testing.T) {
+	var seen sync.Map
+	a := NewAsyncObserver(ObserverFunc(func(s Stats) { seen.Store(s.N, true) }), 4096)
+	defer a.Close()
+
+	// Each producer's own Stats must have been delivered once its Flush returns, however the
+	// other producers' sends interleave with it.
+	var wg sync.WaitGroup
+	var missed atomic.Int64
+	for p := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				n := p*1000 + i
+				a.Observe(Stats{N: n})
+				a.Flush()
+				if _, ok := seen.Load(n); !ok {
+					missed.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if missed.Load() != 0 || a.Dropped() != 0 {
+		t.Fatalf("Flush returned before %d accepted Stats were delivered (%d dropped)", missed.Load(), a.Dropped())
+	}
+}
+
+func TestAsyncObserverDropsWhenFull(t *testing.T) {
+	release := make(chan struct{})
+	var delivered atomic.Int64
+	a := NewAsyncObserver(ObserverFunc(func(Stats) {
+		<-release
+		delivered.Add(1)
+	}), 2)
+
+	// One event is held by the blocked observer, two fill the buffer, the rest are dropped.
+	for range 10 {
+		a.Observe(Stats{})
+	}
+	if a.Dropped() < 7 {
+		t.Fatalf("dropped %d events, expected at least 7", a.Dropped())
+	}
+	close(release)
+	a.Close()
+
+	if got := delivered.Load() + int64(a.Dropped()); got != 10 {
+		t.Fatalf("delivered %d and dropped %d, expected 10 in total", delivered.Load(), a.Dropped())
+	}
+
+	a.Observe(Stats{})
+	if got := delivered.Load() + int64(a.Dropped()); got != 11 {
+		t.Fatalf("Observe after Close was not dropped")
+	}
+}
+
+func TestAsyncObserverRecoversPanics(t *testing.T) {
+	var delivered atomic.Int64
+	a := NewAsyncObserver(ObserverFunc(func(s Stats) {
+		if s.N == 1 {
+			panic("observer failure")
+		}
+		delivered.Add(1)
+	}), 16)
+
+	a.Observe(Stats{N: 1})
+	a.Observe(Stats{N: 2})
+	a.Observe(Stats{N: 1})
+	a.Observe(Stats{N: 3})
+	a.Close()
+
+	if a.Panics() != 2 || delivered.Load() != 2 {
+		t.Fatalf("got %d panics and %d deliveries, expected 2 and 2", a.Panics(), delivered.Load())
+	}
+}
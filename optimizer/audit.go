@@ -0,0 +1,186 @@
+package optimization
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const modulePath = "github.com/greyskp/cost_optimization"
+
+// Version is the version of this module recorded in audit records, as found in the build
+// information of the binary, or "(devel)" when the module was not built as a versioned dependency.
+var Version = moduleVersion()
+
+var ErrAuditTampered = errors.New("audit record digest does not match its content")
+var ErrAuditMismatch = errors.New("audit record does not match the decision")
+var ErrAuditKey = errors.New("audit key must not be empty")
+
+// AuditRecord is a tamper-evident record of one CostOptimization decision (see WithAudit).
+// Inputs and outputs are identified by SHA-256 hashes of their canonical encodings: a
+// little-endian uint64 length followed by the float64 bits of each cost, or one byte per selection
+// flag. Digest is an HMAC-SHA256 of every other field under the caller's key, so only someone
+// holding the key can produce a record that VerifyAudit accepts.
+type AuditRecord struct {
+	Version      string            `json:"version"`
+	Timestamp    time.Time         `json:"timestamp"`
+	N            int               `json:"n"`
+	InputSHA256  string            `json:"input_sha256"`
+	Options      map[string]string `json:"options"`
+	OutputSHA256 string            `json:"output_sha256"`
+	TotalCost    float64           `json:"total_cost"` // encoded as a string in JSON so ±Inf survive
+	Digest       string            `json:"digest"`
+}
+
+type auditRecordJSON AuditRecord
+
+// MarshalJSON encodes TotalCost as a string, since JSON numbers cannot hold infinities.
+func (a AuditRecord) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		auditRecordJSON
+		TotalCost string `json:"total_cost"`
+	}{auditRecordJSON(a), strconv.FormatFloat(a.TotalCost, 'g', -1, 64)})
+}
+
+func (a *AuditRecord) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		auditRecordJSON
+		TotalCost string `json:"total_cost"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	total, err := strconv.ParseFloat(raw.TotalCost, 64)
+	if err != nil {
+		return fmt.Errorf("%w: total_cost %q", ErrInvalidFormat, raw.TotalCost)
+	}
+	*a = AuditRecord(raw.auditRecordJSON)
+	a.TotalCost = total
+	return nil
+}
+
+// newAuditRecord builds the sealed record of a successful call.
+func newAuditRecord(prices []float64, cfg options, out []int) AuditRecord {
+	total, _ := TotalCost(prices, out)
+	rec := AuditRecord{
+		Version:      Version,
+		Timestamp:    time.Now().UTC(),
+		N:            len(prices),
+		InputSHA256:  hashCosts(prices),
+		Options:      cfg.describe(),
+		OutputSHA256: hashSelection(out),
+		TotalCost:    total,
+	}
+	rec.Digest = hex.EncodeToString(rec.digest(cfg.auditKey))
+	return rec
+}
+
+// VerifyAudit checks that rec was sealed with key and not altered since, that it describes costs
+// and selection, and that CostOptimization with the recorded options still makes the same decision.
+func VerifyAudit(rec AuditRecord, key []byte, costs []float64, selection []int) error {
+	if len(key) == 0 {
+		return ErrAuditKey
+	}
+	digest, err := hex.DecodeString(rec.Digest)
+	if err != nil || !hmac.Equal(digest, rec.digest(key)) {
+		return ErrAuditTampered
+	}
+	if rec.N != len(costs) || rec.InputSHA256 != hashCosts(costs) {
+		return fmt.Errorf("%w: input hash", ErrAuditMismatch)
+	}
+	if rec.OutputSHA256 != hashSelection(selection) {
+		return fmt.Errorf("%w: output hash", ErrAuditMismatch)
+	}
+	total, err := TotalCost(costs, selection)
+	if err != nil {
+		return err
+	}
+	if math.Float64bits(total) != math.Float64bits(rec.TotalCost) {
+		return fmt.Errorf("%w: total cost", ErrAuditMismatch)
+	}
+
+	opts, err := optionsFromDescription(rec.Options)
+	if err != nil {
+		return err
+	}
+	replayed, err := CostOptimization(costs, opts...)
+	if err != nil {
+		return err
+	}
+	if !slices.Equal(replayed, selection) {
+		return fmt.Errorf("%w: decision is not reproducible with library version %s", ErrAuditMismatch, Version)
+	}
+	return nil
+}
+
+// digest authenticates every field but Digest, in a fixed order, under key.
+func (a AuditRecord) digest(key []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	write := func(s string) {
+		h.Write(binary.LittleEndian.AppendUint64(nil, uint64(len(s))))
+		h.Write([]byte(s))
+	}
+
+	write(a.Version)
+	write(a.Timestamp.UTC().Format(time.RFC3339Nano))
+	write(strconv.Itoa(a.N))
+	write(a.InputSHA256)
+	for _, k := range slices.Sorted(maps.Keys(a.Options)) {
+		write(k)
+		write(a.Options[k])
+	}
+	write(a.OutputSHA256)
+	write(strconv.FormatUint(math.Float64bits(a.TotalCost), 16))
+	return h.Sum(nil)
+}
+
+// moduleVersion looks this module up in the build information, following replacements.
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(devel)"
+	}
+	if info.Main.Path == modulePath && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != modulePath {
+			continue
+		}
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		if dep.Version != "" {
+			return dep.Version
+		}
+	}
+	return "(devel)"
+}
+
+func hashCosts(costs []float64) string {
+	buf := binary.LittleEndian.AppendUint64(make([]byte, 0, 8+8*len(costs)), uint64(len(costs)))
+	for _, c := range costs {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(c))
+	}
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
+
+func hashSelection(selection []int) string {
+	buf := binary.LittleEndian.AppendUint64(make([]byte, 0, 8+len(selection)), uint64(len(selection)))
+	for _, v := range selection {
+		buf = append(buf, byte(v))
+	}
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
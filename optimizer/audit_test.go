@@ -0,0 +1,118 @@
+package optimization
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+var auditKey = []byte("audit test key")
+
+func TestAuditRecord(t *testing.T) {
+	costs := []float64{-10, 17, 15, -40, 20, 3, 8}
+	var rec AuditRecord
+	out, err := CostOptimization(costs, WithAudit(&rec, auditKey), WithSolver(SortSolver{}))
+	if err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	if rec.Version != Version || rec.N != len(costs) || rec.Options["solver"] != "sort" || rec.TotalCost != -39 {
+		t.Fatalf("unexpected audit record %+v", rec)
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		t.Fatalf("json.Marshal returned unexpected error: %v", err)
+	}
+	var decoded AuditRecord
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal returned unexpected error: %v", err)
+	}
+	if err := VerifyAudit(decoded, auditKey, costs, out); err != nil {
+		t.Fatalf("VerifyAudit rejected a genuine record: %v", err)
+	}
+
+	changed := append([]float64(nil), costs...)
+	changed[1] = 16
+	if err := VerifyAudit(decoded, auditKey, changed, out); !errors.Is(err, ErrAuditMismatch) {
+		t.Fatalf("expected ErrAuditMismatch for changed input, got %v", err)
+	}
+	flipped := append([]int(nil), out...)
+	flipped[4] ^= 1
+	if err := VerifyAudit(decoded, auditKey, costs, flipped); !errors.Is(err, ErrAuditMismatch) {
+		t.Fatalf("expected ErrAuditMismatch for changed output, got %v", err)
+	}
+
+	tampered := decoded
+	tampered.TotalCost = -50
+	if err := VerifyAudit(tampered, auditKey, costs, out); !errors.Is(err, ErrAuditTampered) {
+		t.Fatalf("expected ErrAuditTampered, got %v", err)
+	}
+}
+
+func TestAuditRecordKey(t *testing.T) {
+	costs := []float64{4, -1, 3, 2}
+	var rec AuditRecord
+	out, err := CostOptimization(costs, WithAudit(&rec, auditKey))
+	if err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+
+	if err := VerifyAudit(rec, []byte("another key"), costs, out); !errors.Is(err, ErrAuditTampered) {
+		t.Fatalf("expected ErrAuditTampered for the wrong key, got %v", err)
+	}
+
+	// Without the key, an edited record cannot be resealed.
+	forged := rec
+	forged.Options = map[string]string{"solver": "sort"}
+	forged.Digest = hex.EncodeToString(forged.digest([]byte("guessed key")))
+	if err := VerifyAudit(forged, auditKey, costs, out); !errors.Is(err, ErrAuditTampered) {
+		t.Fatalf("expected ErrAuditTampered for a resealed record, got %v", err)
+	}
+	forged.Digest = "not hex"
+	if err := VerifyAudit(forged, auditKey, costs, out); !errors.Is(err, ErrAuditTampered) {
+		t.Fatalf("expected ErrAuditTampered for a malformed digest, got %v", err)
+	}
+
+	if err := VerifyAudit(rec, nil, costs, out); !errors.Is(err, ErrAuditKey) {
+		t.Fatalf("expected ErrAuditKey, got %v", err)
+	}
+	if _, err := CostOptimization(costs, WithAudit(&rec, nil)); !errors.Is(err, ErrAuditKey) {
+		t.Fatalf("expected ErrAuditKey, got %v", err)
+	}
+}
+
+func TestAuditRecordInfiniteTotal(t *testing.T) {
+	costs := []float64{math.Inf(-1), 1, 2}
+	var rec AuditRecord
+	out, err := CostOptimization(costs, WithAudit(&rec, auditKey))
+	if err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		t.Fatalf("json.Marshal returned unexpected error: %v", err)
+	}
+	var decoded AuditRecord
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal returned unexpected error: %v", err)
+	}
+	if !math.IsInf(decoded.TotalCost, -1) {
+		t.Fatalf("total cost decoded as %v, expected -Inf", decoded.TotalCost)
+	}
+	if err := VerifyAudit(decoded, auditKey, costs, out); err != nil {
+		t.Fatalf("VerifyAudit rejected a genuine record: %v", err)
+	}
+}
+
+func TestAuditRecordNotFilledOnError(t *testing.T) {
+	rec := AuditRecord{Version: "untouched"}
+	if _, err := CostOptimization([]float64{1, math.NaN()}, WithAudit(&rec, auditKey)); err == nil {
+		t.Fatal("expected an error for NaN input")
+	}
+	if rec.Version != "untouched" {
+		t.Fatalf("audit record overwritten on error: %+v", rec)
+	}
+}
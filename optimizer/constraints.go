@@ -0,0 +1,516 @@
+package optimization
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidConstraint = errors.New("constraint refers to an item out of range")
+var ErrConflictingOptions = errors.New("spacing constraints cannot be combined with conflicts or implications")
+var ErrSearchLimit = errors.New("no selection satisfying the constraints was found within the search limits")
+
+const (
+	PathBranchAndBound      Path = "branch_and_bound"     // conflicts and implications solved exactly
+	PathConstraintHeuristic Path = "constraint_heuristic" // conflicts and implications solved greedily
+)
+
+// maxBranchItems is the number of constrained items up to which the exact search is tried, and
+// maxBranchNodes the number of search nodes after which it gives up on proving optimality.
+// maxGreedyRetries bounds how many times the heuristic starts over without the item that blocked
+// the most others.
+const (
+	maxBranchItems   = 64
+	maxBranchNodes   = 1 << 20
+	maxGreedyRetries = 64
+)
+
+// Conflict forbids selecting items A and B together.
+type Conflict struct {
+	A, B int
+}
+
+// Implication requires item Then to be selected whenever item If is.
+type Implication struct {
+	If, Then int
+}
+
+// ConstraintReport describes how conflicts and implications shaped a selection (see WithConstraintReport).
+type ConstraintReport struct {
+	// Exact is false when the result comes from the heuristic, or from a search that ran out of
+	// budget, and may therefore cost more than the optimum.
+	Exact bool
+	// Binding constraints are those the unconstrained selection violated, i.e. the ones that moved
+	// the result away from what CostOptimization would return without them.
+	Conflicts    []Conflict
+	Implications []Implication
+}
+
+// WithConflicts declares pairs of items that must not be selected together.
+func WithConflicts(conflicts ...Conflict) Option {
+	return func(opt *options) {
+		opt.conflicts = append(opt.conflicts, conflicts...)
+	}
+}
+
+// WithImplications declares items that can only be selected together with another item.
+func WithImplications(implications ...Implication) Option {
+	return func(opt *options) {
+		opt.implications = append(opt.implications, implications...)
+	}
+}
+
+// WithConstraintReport fills dst with the ConstraintReport of each successful CostOptimization call,
+// and of each call failing with ErrSearchLimit.
+func WithConstraintReport(dst *ConstraintReport) Option {
+	return func(opt *options) {
+		opt.constraintReport = dst
+	}
+}
+
+// itemConstrained reports whether conflicts or implications are declared.
+func (o options) itemConstrained() bool {
+	return len(o.conflicts) > 0 || len(o.implications) > 0
+}
+
+// applyItemConstraints turns the unconstrained selection in res into the cheapest selection that
+// also satisfies the conflicts and implications of cfg. When res already satisfies them it is
+// optimal and kept. Otherwise the items that appear in a constraint are searched by branch and
+// bound, the others being completed optimally at each leaf; beyond maxBranchItems constrained items
+// a greedy heuristic is used instead. A search that runs out of nodes keeps the best selection found
+// so far. ErrInfeasible is only returned when a complete search proves that no selection exists;
+// when the heuristic or an interrupted search found none, ErrSearchLimit is returned along with
+// the report.
+func applyItemConstraints(prices []float64, res []int, cfg options, stats *Stats) (ConstraintReport, error) {
+	n := len(prices)
+	for _, c := range cfg.conflicts {
+		if c.A < 0 || c.A >= n || c.B < 0 || c.B >= n {
+			return ConstraintReport{}, fmt.Errorf("%w: conflict %d/%d", ErrInvalidConstraint, c.A, c.B)
+		}
+	}
+	for _, im := range cfg.implications {
+		if im.If < 0 || im.If >= n || im.Then < 0 || im.Then >= n {
+			return ConstraintReport{}, fmt.Errorf("%w: implication %d>%d", ErrInvalidConstraint, im.If, im.Then)
+		}
+	}
+
+	report := ConstraintReport{Exact: true}
+	for _, c := range cfg.conflicts {
+		if res[c.A] == 1 && res[c.B] == 1 {
+			report.Conflicts = append(report.Conflicts, c)
+		}
+	}
+	for _, im := range cfg.implications {
+		if res[im.If] == 1 && res[im.Then] == 0 {
+			report.Implications = append(report.Implications, im)
+		}
+	}
+	if len(report.Conflicts) == 0 && len(report.Implications) == 0 {
+		return report, nil
+	}
+
+	for _, value := range prices {
+		if math.IsInf(value, 0) {
+			return ConstraintReport{}, ErrInfiniteCost
+		}
+	}
+
+	g := newConstraintGraph(n, cfg)
+	need := requiredCount(n)
+	best, found := retryGreedyConstrained(prices, g, need)
+	stats.Path = PathConstraintHeuristic
+	report.Exact = false
+
+	if len(g.items) <= maxBranchItems {
+		s := newBranchSearch(prices, g, need, res)
+		if found {
+			s.record(best)
+		}
+		s.branch(0, 0, 0)
+		if s.found {
+			best, found = s.selection(), true
+		}
+		stats.Path = PathBranchAndBound
+		report.Exact = s.nodes <= maxBranchNodes
+	}
+	if !found && report.Exact {
+		return ConstraintReport{}, ErrInfeasible
+	}
+	if !found {
+		return report, ErrSearchLimit
+	}
+
+	copy(res, best)
+	stats.SelectedCount = countSelected(res)
+	return report, nil
+}
+
+// constraintGraph holds the constraints as adjacency lists, plus the items that appear in one.
+type constraintGraph struct {
+	conflicts  [][]int
+	requires   [][]int
+	requiredBy [][]int
+	items      []int
+	inItems    []bool
+}
+
+func newConstraintGraph(n int, cfg options) *constraintGraph {
+	g := &constraintGraph{
+		conflicts:  make([][]int, n),
+		requires:   make([][]int, n),
+		requiredBy: make([][]int, n),
+		inItems:    make([]bool, n),
+	}
+	add := func(items ...int) {
+		for _, i := range items {
+			if !g.inItems[i] {
+				g.inItems[i] = true
+				g.items = append(g.items, i)
+			}
+		}
+	}
+	for _, c := range cfg.conflicts {
+		g.conflicts[c.A] = append(g.conflicts[c.A], c.B)
+		g.conflicts[c.B] = append(g.conflicts[c.B], c.A)
+		add(c.A, c.B)
+	}
+	for _, im := range cfg.implications {
+		g.requires[im.If] = append(g.requires[im.If], im.Then)
+		g.requiredBy[im.Then] = append(g.requiredBy[im.Then], im.If)
+		add(im.If, im.Then)
+	}
+	return g
+}
+
+// retryGreedyConstrained runs greedyConstrained and, while it falls short of need, runs it again
+// without the selected item that blocked the most candidates through its conflicts.
+func retryGreedyConstrained(prices []float64, g *constraintGraph, need int) ([]int, bool) {
+	banned := make([]bool, len(prices))
+	for range maxGreedyRetries + 1 {
+		sel, ok, blocker := greedyConstrained(prices, g, need, banned)
+		if ok || blocker < 0 {
+			return sel, ok
+		}
+		banned[blocker] = true
+	}
+	return nil, false
+}
+
+// greedyConstrained adds items in increasing order of the cost of their implication closure, skipping
+// those whose closure holds a banned item or conflicts with the selection, while the closure lowers
+// the total or the selection is still short of need. It reports false when it cannot reach need,
+// along with the selected item whose conflicts turned down the most closures (-1 if none did).
+func greedyConstrained(prices []float64, g *constraintGraph, need int, banned []bool) ([]int, bool, int) {
+	n := len(prices)
+	sel := make([]int, n)
+	count := 0
+	blocked := make([]int, n)
+
+	// mark[j] == stamp tells that j belongs to the closure computed last.
+	mark := make([]int, n)
+	stamp := 0
+	closure := func(i int) []int {
+		stamp++
+		members := []int{i}
+		mark[i] = stamp
+		for k := 0; k < len(members); k++ {
+			for _, j := range g.requires[members[k]] {
+				if sel[j] == 0 && mark[j] != stamp {
+					mark[j] = stamp
+					members = append(members, j)
+				}
+			}
+		}
+		return members
+	}
+	closureCost := func(members []int) float64 {
+		total := 0.0
+		for _, j := range members {
+			total += prices[j]
+		}
+		return total
+	}
+
+	order := make([]int, n)
+	keys := make([]float64, n)
+	for i := range order {
+		order[i] = i
+		keys[i] = closureCost(closure(i))
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return cmp.Compare(keys[a], keys[b]) })
+
+	for _, i := range order {
+		if sel[i] == 1 {
+			continue
+		}
+		members := closure(i)
+		if count >= need && closureCost(members) >= 0 {
+			continue
+		}
+		ok := true
+		for _, j := range members {
+			if banned[j] {
+				ok = false
+			}
+			for _, k := range g.conflicts[j] {
+				if sel[k] == 1 {
+					blocked[k]++
+					ok = false
+				}
+				if mark[k] == stamp {
+					ok = false
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+		for _, j := range members {
+			sel[j] = 1
+		}
+		count += len(members)
+	}
+	if count >= need {
+		return sel, true, -1
+	}
+
+	blocker := -1
+	for i, b := range blocked {
+		if b > 0 && (blocker < 0 || b > blocked[blocker]) {
+			blocker = i
+		}
+	}
+	return sel, false, blocker
+}
+
+// branchSearch is a depth-first branch and bound over the constrained items. They are taken one
+// connected component of the constraint graph at a time, so every constraint is settled soon after
+// its first item, and each item first gets the value it has in the unconstrained selection. The
+// free items are sorted once; the bound selects, ignoring the constraints, every undecided negative
+// cost and the smallest other costs up to need.
+type branchSearch struct {
+	prices []float64
+	g      *constraintGraph
+	need   int
+
+	order   []int     // branching order
+	prefer  []int8    // value tried first, per item
+	byCost  []int     // constrained items by increasing cost
+	free    []int     // unconstrained items by increasing cost
+	freeP   []float64 // freeP[k] is the sum of the costs of free[:k]
+	freeNeg int
+	scratch []float64
+
+	value []int8 // -1 while undecided
+	nodes int
+
+	found     bool
+	bestTotal float64
+	bestValue []int8
+	bestFree  int // number of free items taken, in order
+}
+
+func newBranchSearch(prices []float64, g *constraintGraph, need int, unconstrained []int) *branchSearch {
+	byCost := func(a, b int) int { return compareCost(cost{prices[a], a}, cost{prices[b], b}) }
+	s := &branchSearch{
+		prices:    prices,
+		g:         g,
+		need:      need,
+		prefer:    make([]int8, len(prices)),
+		byCost:    slices.SortedFunc(slices.Values(g.items), byCost),
+		scratch:   make([]float64, 0, len(g.items)),
+		value:     make([]int8, len(prices)),
+		bestTotal: math.Inf(1),
+	}
+	for i := range prices {
+		s.value[i] = -1
+		s.prefer[i] = int8(unconstrained[i])
+		if !g.inItems[i] {
+			s.free = append(s.free, i)
+		}
+	}
+	slices.SortFunc(s.free, byCost)
+	s.freeP = prefixSums(prices, s.free)
+	for _, i := range s.free {
+		if prices[i] < 0 {
+			s.freeNeg++
+		}
+	}
+
+	// Breadth-first over the constraints, starting each component from its cheapest item.
+	seen := make(map[int]bool, len(g.items))
+	for _, root := range s.byCost {
+		if seen[root] {
+			continue
+		}
+		seen[root] = true
+		s.order = append(s.order, root)
+		for k := len(s.order) - 1; k < len(s.order); k++ {
+			i := s.order[k]
+			for _, adj := range [3][]int{g.conflicts[i], g.requires[i], g.requiredBy[i]} {
+				for _, j := range adj {
+					if !seen[j] {
+						seen[j] = true
+						s.order = append(s.order, j)
+					}
+				}
+			}
+		}
+	}
+	return s
+}
+
+func prefixSums(prices []float64, items []int) []float64 {
+	sums := make([]float64, len(items)+1)
+	for k, i := range items {
+		sums[k+1] = sums[k] + prices[i]
+	}
+	return sums
+}
+
+// bound returns a lower bound on any completion of a node with count items selected for total,
+// and whether the node can still reach need at all.
+func (s *branchSearch) bound(count int, total float64) (float64, bool) {
+	missing := s.need - count - s.freeNeg
+	total += s.freeP[s.freeNeg]
+	s.scratch = s.scratch[:0]
+	for _, i := range s.byCost {
+		switch {
+		case s.value[i] >= 0:
+		case s.prices[i] < 0:
+			total += s.prices[i]
+			missing--
+		default:
+			s.scratch = append(s.scratch, s.prices[i])
+		}
+	}
+	if missing <= 0 {
+		return total, true
+	}
+
+	// Take the smallest missing non-negatives from both sorted lists.
+	best, ok := math.Inf(1), false
+	taken := 0.0
+	for a := 0; a <= missing && a <= len(s.scratch); a++ {
+		if a > 0 {
+			taken += s.scratch[a-1]
+		}
+		if b := missing - a; s.freeNeg+b <= len(s.free) {
+			best, ok = min(best, taken+s.freeP[s.freeNeg+b]-s.freeP[s.freeNeg]), true
+		}
+	}
+	return total + best, ok
+}
+
+// allowed reports whether item i can take value v given the items decided so far.
+func (s *branchSearch) allowed(i int, v int8) bool {
+	if v == 1 {
+		for _, j := range s.g.conflicts[i] {
+			if s.value[j] == 1 || j == i {
+				return false
+			}
+		}
+		for _, j := range s.g.requires[i] {
+			if s.value[j] == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	for _, j := range s.g.requiredBy[i] {
+		if s.value[j] == 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *branchSearch) branch(depth, count int, total float64) {
+	s.nodes++
+	if s.nodes > maxBranchNodes {
+		return
+	}
+	// The bound and the incumbent are summed in different orders; the tolerance keeps rounding from
+	// reopening subtrees that cannot do better.
+	lb, ok := s.bound(count, total)
+	if !ok || lb >= s.bestTotal-1e-9*max(1, math.Abs(s.bestTotal)) {
+		return
+	}
+	if depth == len(s.order) {
+		take := max(s.freeNeg, s.need-count)
+		s.bestTotal = total + s.freeP[take]
+		s.bestValue = slices.Clone(s.value)
+		s.bestFree = take
+		s.found = true
+		return
+	}
+
+	i := s.order[depth]
+	first := s.prefer[i]
+	for _, v := range [2]int8{first, 1 - first} {
+		s.value[i] = -1
+		if !s.allowed(i, v) {
+			continue
+		}
+		s.value[i] = v
+		if v == 1 {
+			s.branch(depth+1, count+1, total+s.prices[i])
+		} else {
+			s.branch(depth+1, count, total)
+		}
+	}
+	s.value[i] = -1
+}
+
+// record makes sel the incumbent, so the search only explores cheaper selections.
+func (s *branchSearch) record(sel []int) {
+	s.bestTotal, _ = TotalCost(s.prices, sel)
+}
+
+// selection returns the best selection found by the search.
+func (s *branchSearch) selection() []int {
+	res := make([]int, len(s.prices))
+	for _, i := range s.order {
+		res[i] = int(s.bestValue[i])
+	}
+	for _, i := range s.free[:s.bestFree] {
+		res[i] = 1
+	}
+	return res
+}
+
+// formatConflicts, formatImplications and their parsers encode constraints for describe, as
+// "a/b,c/d" and "a>b,c>d".
+func formatConflicts(conflicts []Conflict) string {
+	parts := make([]string, len(conflicts))
+	for k, c := range conflicts {
+		parts[k] = strconv.Itoa(c.A) + "/" + strconv.Itoa(c.B)
+	}
+	return strings.Join(parts, ",")
+}
+
+func formatImplications(implications []Implication) string {
+	parts := make([]string, len(implications))
+	for k, im := range implications {
+		parts[k] = strconv.Itoa(im.If) + ">" + strconv.Itoa(im.Then)
+	}
+	return strings.Join(parts, ",")
+}
+
+func parsePairs(s, sep string) ([][2]int, error) {
+	var pairs [][2]int
+	for _, part := range strings.Split(s, ",") {
+		a, b, ok := strings.Cut(part, sep)
+		x, errA := strconv.Atoi(a)
+		y, errB := strconv.Atoi(b)
+		if !ok || errA != nil || errB != nil {
+			return nil, fmt.Errorf("%w: constraint %s", ErrInvalidFormat, strconv.Quote(part))
+		}
+		pairs = append(pairs, [2]int{x, y})
+	}
+	return pairs, nil
+}
@@ -0,0 +1,235 @@
+package optimization
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestConflicts(t *testing.T) {
+	costs := []float64{3, 1, 2, 7, 9, 8}
+	stats := &statsRecorder{}
+	var report ConstraintReport
+
+	// Items 1 and 2 are two vendors for the same contract; 0 and 5 never meet in the result.
+	conflicts := []Conflict{{1, 2}, {0, 5}}
+	out, err := CostOptimization(costs, WithConflicts(conflicts...), WithConstraintReport(&report), WithObserver(stats))
+	if err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	if expected := []int{1, 1, 0, 1, 0, 0}; !slices.Equal(out, expected) {
+		t.Fatalf("output %v, expected %v", out, expected)
+	}
+	if !report.Exact || !slices.Equal(report.Conflicts, conflicts[:1]) || len(report.Implications) != 0 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if stats.last.Path != PathBranchAndBound || stats.last.TotalCost != 11 {
+		t.Fatalf("unexpected stats %+v", stats.last)
+	}
+}
+
+func TestImplications(t *testing.T) {
+	costs := []float64{-4, 6, 1, 2, 5, 3}
+	var report ConstraintReport
+
+	// The license (0) requires its base product (1), and is still worth it: -4 + 6 < 3.
+	out, err := CostOptimization(costs, WithImplications(Implication{0, 1}), WithConstraintReport(&report))
+	if err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	if expected := []int{1, 1, 1, 0, 0, 0}; !slices.Equal(out, expected) {
+		t.Fatalf("output %v, expected %v", out, expected)
+	}
+	if !report.Exact || !slices.Equal(report.Implications, []Implication{{0, 1}}) {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	// Constraints the unconstrained result already meets are not binding.
+	out, _ = CostOptimization(costs, WithImplications(Implication{2, 3}), WithConstraintReport(&report))
+	if plain, _ := CostOptimization(costs); !slices.Equal(out, plain) || len(report.Implications) != 0 {
+		t.Fatalf("output %v, report %+v", out, report)
+	}
+}
+
+func TestItemConstraintsAreOptimal(t *testing.T) {
+	r := rand.New(rand.NewPCG(50, 2))
+	for range 500 {
+		n := 1 + r.IntN(10)
+		costs := make([]float64, n)
+		for i := range costs {
+			costs[i] = float64(r.IntN(21) - 6)
+		}
+		var conflicts []Conflict
+		var implications []Implication
+		for range r.IntN(4) {
+			conflicts = append(conflicts, Conflict{r.IntN(n), r.IntN(n)})
+		}
+		for range r.IntN(4) {
+			implications = append(implications, Implication{r.IntN(n), r.IntN(n)})
+		}
+
+		var report ConstraintReport
+		best, feasible := bruteForceConstrained(costs, conflicts, implications)
+		out, err := CostOptimization(costs, WithConflicts(conflicts...), WithImplications(implications...), WithConstraintReport(&report))
+		if !feasible {
+			if !errors.Is(err, ErrInfeasible) {
+				t.Fatalf("costs %v %v %v: expected ErrInfeasible, got %v", costs, conflicts, implications, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("CostOptimization returned unexpected error: %v", err)
+		}
+		if !satisfiesItemConstraints(out, conflicts, implications) {
+			t.Fatalf("costs %v %v %v: output %v breaks the constraints", costs, conflicts, implications, out)
+		}
+		if total, _ := TotalCost(costs, out); total != best || !report.Exact {
+			t.Fatalf("costs %v %v %v: total %v (exact %v), optimum %v", costs, conflicts, implications, total, report.Exact, best)
+		}
+	}
+}
+
+func TestItemConstraintsHeuristic(t *testing.T) {
+	costs := randFloats(-10, 100, 1000)
+	// Chain every even item to the next one and make it conflict with the item after that.
+	var conflicts []Conflict
+	var implications []Implication
+	for i := 0; i+2 < len(costs); i += 2 {
+		implications = append(implications, Implication{i, i + 1})
+		conflicts = append(conflicts, Conflict{i, i + 2})
+	}
+	var report ConstraintReport
+	stats := &statsRecorder{}
+	out, err := CostOptimization(costs, WithConflicts(conflicts...), WithImplications(implications...),
+		WithConstraintReport(&report), WithObserver(stats))
+	if err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	if report.Exact || stats.last.Path != PathConstraintHeuristic {
+		t.Fatalf("exact %v, path %s: expected the heuristic", report.Exact, stats.last.Path)
+	}
+	if countOnes(out) < requiredCount(len(costs)) || !satisfiesItemConstraints(out, conflicts, implications) {
+		t.Fatal("heuristic selection is not feasible")
+	}
+}
+
+func TestItemConstraintsGreedyRetry(t *testing.T) {
+	// Item 0 is the cheapest and conflicts with every other one, so a greedy seeded with it can
+	// never reach ⌈n/2⌉; items 1..65 are a feasible (and optimal) selection.
+	costs := make([]float64, 130)
+	costs[0] = -100
+	var conflicts []Conflict
+	for i := 1; i < len(costs); i++ {
+		costs[i] = float64(i)
+		conflicts = append(conflicts, Conflict{0, i})
+	}
+
+	var report ConstraintReport
+	out, err := CostOptimization(costs, WithConflicts(conflicts...), WithConstraintReport(&report))
+	if err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	if !satisfiesItemConstraints(out, conflicts, nil) || countOnes(out) != 65 || out[0] != 0 {
+		t.Fatalf("selection of %d items with item 0 = %d, expected items 1..65", countOnes(out), out[0])
+	}
+	if total, _ := TotalCost(costs, out); total != 65*66/2 {
+		t.Fatalf("total %v, expected %v", total, 65*66/2)
+	}
+	if report.Exact {
+		t.Fatal("a heuristic result was reported as exact")
+	}
+}
+
+func TestItemConstraintsSearchLimit(t *testing.T) {
+	// At most one item of each pair can be selected, and items 0 and 1 require each other, so no
+	// selection reaches 65 items; with 130 constrained items only the heuristic runs, which
+	// cannot prove it.
+	costs := make([]float64, 130)
+	var conflicts []Conflict
+	for i := 0; i < len(costs); i += 2 {
+		costs[i], costs[i+1] = float64(i), float64(i+1)
+		conflicts = append(conflicts, Conflict{i, i + 1})
+	}
+	implications := []Implication{{0, 1}, {1, 0}}
+
+	report := ConstraintReport{Exact: true}
+	_, err := CostOptimization(costs, WithConflicts(conflicts...), WithImplications(implications...), WithConstraintReport(&report))
+	if !errors.Is(err, ErrSearchLimit) {
+		t.Fatalf("expected %v, got %v", ErrSearchLimit, err)
+	}
+	if report.Exact || len(report.Conflicts) == 0 {
+		t.Fatalf("report %+v, expected the binding conflicts with Exact false", report)
+	}
+
+	// The same constraints on few enough items are proven infeasible.
+	_, err = CostOptimization(costs[:8], WithConflicts(conflicts[:4]...), WithImplications(implications...))
+	if !errors.Is(err, ErrInfeasible) {
+		t.Fatalf("expected %v, got %v", ErrInfeasible, err)
+	}
+}
+
+func TestItemConstraintsErrors(t *testing.T) {
+	costs := []float64{1, 2, 3}
+	cases := []struct {
+		opts []Option
+		err  error
+	}{
+		{[]Option{WithConflicts(Conflict{0, 3})}, ErrInvalidConstraint},
+		{[]Option{WithImplications(Implication{-1, 0})}, ErrInvalidConstraint},
+		{[]Option{WithConflicts(Conflict{0, 1}, Conflict{1, 2}, Conflict{0, 2})}, ErrInfeasible},
+		{[]Option{WithConflicts(Conflict{0, 1}), WithNoAdjacent()}, ErrConflictingOptions},
+	}
+	for _, c := range cases {
+		if _, err := CostOptimization(costs, c.opts...); !errors.Is(err, c.err) {
+			t.Errorf("expected %v, got %v", c.err, err)
+		}
+	}
+}
+
+func TestItemConstraintsAudit(t *testing.T) {
+	costs := []float64{3, 1, 2, 7, 9, 8}
+	var rec AuditRecord
+	out, err := CostOptimization(costs, WithConflicts(Conflict{1, 2}), WithImplications(Implication{0, 4}), WithAudit(&rec, auditKey))
+	if err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	if rec.Options["conflicts"] != "1/2" || rec.Options["implications"] != "0>4" {
+		t.Fatalf("options not described: %v", rec.Options)
+	}
+	if err := VerifyAudit(rec, auditKey, costs, out); err != nil {
+		t.Fatalf("VerifyAudit rejected a constrained decision: %v", err)
+	}
+}
+
+func satisfiesItemConstraints(sel []int, conflicts []Conflict, implications []Implication) bool {
+	for _, c := range conflicts {
+		if sel[c.A] == 1 && sel[c.B] == 1 {
+			return false
+		}
+	}
+	for _, im := range implications {
+		if sel[im.If] == 1 && sel[im.Then] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bruteForceConstrained returns the smallest total over every feasible selection of at least ⌈n/2⌉ items.
+func bruteForceConstrained(costs []float64, conflicts []Conflict, implications []Implication) (float64, bool) {
+	n := len(costs)
+	best, feasible := math.Inf(1), false
+	sel := make([]int, n)
+	for mask := range 1 << n {
+		for i := range sel {
+			sel[i] = mask >> i & 1
+		}
+		if countOnes(sel) >= requiredCount(n) && satisfiesItemConstraints(sel, conflicts, implications) {
+			total, _ := TotalCost(costs, sel)
+			best, feasible = min(best, total), true
+		}
+	}
+	return best, feasible
+}
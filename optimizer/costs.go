@@ -0,0 +1,66 @@
+package optimization
+
+import "iter"
+
+// Costs gives the optimizer indexed access to costs kept in the caller's own storage,
+// in the spirit of sort.Interface.
+type Costs interface {
+	Len() int
+	Cost(i int) float64
+}
+
+// Selector receives the decision for every index of a Costs.
+type Selector interface {
+	Select(i int, selected bool)
+}
+
+// Float64Costs adapts a []float64 to Costs.
+type Float64Costs []float64
+
+func (c Float64Costs) Len() int           { return len(c) }
+func (c Float64Costs) Cost(i int) float64 { return c[i] }
+
+// IntSelection adapts a []int of the same length as the costs to Selector, storing 0 or 1.
+type IntSelection []int
+
+func (s IntSelection) Select(i int, selected bool) {
+	if selected {
+		s[i] = 1
+	} else {
+		s[i] = 0
+	}
+}
+
+// Optimize applies the CostOptimization rule to costs read through the Costs interface and reports
+// the decision for each index, in increasing order, to sel. Costs are read a few times instead of
+// being copied, and only the candidates for the remaining slots are held in memory.
+func Optimize(costs Costs, sel Selector, opts ...Option) error {
+	selected, err := CostOptimizationSeq2(costsSeq(costs), opts...)
+	if err != nil {
+		return err
+	}
+
+	next := 0
+	for index := range selected {
+		for ; next < index; next++ {
+			sel.Select(next, false)
+		}
+		sel.Select(index, true)
+		next = index + 1
+	}
+	for ; next < costs.Len(); next++ {
+		sel.Select(next, false)
+	}
+
+	return nil
+}
+
+func costsSeq(costs Costs) iter.Seq2[int, float64] {
+	return func(yield func(int, float64) bool) {
+		for i := range costs.Len() {
+			if !yield(i, costs.Cost(i)) {
+				return
+			}
+		}
+	}
+}
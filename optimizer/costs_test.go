@@ -0,0 +1,65 @@
+package optimization
+
+import (
+	"slices"
+	"testing"
+)
+
+type item struct {
+	name  string
+	price float64
+	pick  bool
+}
+
+type items []item
+
+func (s items) Len() int                    { return len(s) }
+func (s items) Cost(i int) float64          { return s[i].price }
+func (s items) Select(i int, selected bool) { s[i].pick = selected }
+
+func TestOptimizeMatchesCostOptimization(t *testing.T) {
+	inputs := [][]float64{
+		{-10, 17, 15, -40, 20},
+		{0.0, 0, 0.0, 0, 0},
+		randFloats(-100.0, 500.0, 333),
+	}
+
+	for _, costs := range inputs {
+		expected, err := CostOptimization(costs)
+		if err != nil {
+			t.Fatalf("CostOptimization returned unexpected error: %v", err)
+		}
+
+		// Pre-fill with ones so stale values would show up.
+		result := make(IntSelection, len(costs))
+		for i := range result {
+			result[i] = 1
+		}
+		if err := Optimize(Float64Costs(costs), result); err != nil {
+			t.Fatalf("Optimize returned unexpected error: %v", err)
+		}
+		if !slices.Equal([]int(result), expected) {
+			t.Fatalf("Optimize got %v, expected %v", result, expected)
+		}
+	}
+}
+
+func TestOptimizeCustomStorage(t *testing.T) {
+	catalog := items{{"a", 987.4, false}, {"b", 684.5, false}, {"c", 6450.7, true}, {"d", 4156.3, true}, {"e", 8.4, false}}
+	if err := Optimize(catalog, catalog); err != nil {
+		t.Fatalf("Optimize returned unexpected error: %v", err)
+	}
+
+	expected := []bool{true, true, false, false, true}
+	for i, it := range catalog {
+		if it.pick != expected[i] {
+			t.Fatalf("item %s got %v, expected %v", it.name, it.pick, expected[i])
+		}
+	}
+}
+
+func TestOptimizeEmpty(t *testing.T) {
+	if err := Optimize(Float64Costs{}, IntSelection{}); err != ErrEmptyInput {
+		t.Fatalf("empty costs got %v, expected %v", err, ErrEmptyInput)
+	}
+}
@@ -0,0 +1,70 @@
+package optimization
+
+import (
+	"fmt"
+	"math"
+)
+
+// SelectionDiff describes how a selection changed between two runs over the same items.
+//
+// The change in total cost is split in two parts that add up to NewTotal-PrevTotal:
+// PriceDelta is what yesterday's selection would cost at today's prices minus what it cost,
+// and SelectionDelta is what the new selection saves or costs over keeping yesterday's one.
+type SelectionDiff struct {
+	Added   []int // selected now, not before
+	Removed []int // selected before, not now
+	Kept    []int // selected in both
+
+	PrevTotal      float64
+	NewTotal       float64
+	PriceDelta     float64
+	SelectionDelta float64
+}
+
+// Diff compares a previous selection with a new one. All four slices must have the same length,
+// and both selections must contain only 0 and 1.
+func Diff(prevCosts []float64, prevSel []int, newCosts []float64, newSel []int) (SelectionDiff, error) {
+	n := len(prevCosts)
+	if len(prevSel) != n || len(newCosts) != n || len(newSel) != n {
+		return SelectionDiff{}, ErrDifferentSizes
+	}
+	for i := range n {
+		if math.IsNaN(prevCosts[i]) || math.IsNaN(newCosts[i]) {
+			return SelectionDiff{}, ErrInvalidNumber
+		}
+		if prevSel[i]&^1 != 0 || newSel[i]&^1 != 0 {
+			return SelectionDiff{}, ErrInvalidSelection
+		}
+	}
+
+	var d SelectionDiff
+	for i := range n {
+		switch was, is := prevSel[i] == 1, newSel[i] == 1; {
+		case was && is:
+			d.Kept = append(d.Kept, i)
+		case is:
+			d.Added = append(d.Added, i)
+		case was:
+			d.Removed = append(d.Removed, i)
+		}
+	}
+
+	d.PrevTotal, _ = TotalCost(prevCosts, prevSel)
+	d.NewTotal, _ = TotalCost(newCosts, newSel)
+	repriced, _ := TotalCost(newCosts, prevSel)
+	d.PriceDelta = repriced - d.PrevTotal
+	d.SelectionDelta = d.NewTotal - repriced
+	return d, nil
+}
+
+// Changed reports whether any item entered or left the selection.
+func (d SelectionDiff) Changed() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0
+}
+
+// Summary returns a one-line human-readable report of the diff.
+func (d SelectionDiff) Summary() string {
+	return fmt.Sprintf("%d added, %d removed, %d kept; total %g -> %g (%+g: prices %+g, selection %+g)",
+		len(d.Added), len(d.Removed), len(d.Kept), d.PrevTotal, d.NewTotal,
+		d.NewTotal-d.PrevTotal, d.PriceDelta, d.SelectionDelta)
+}
@@ -0,0 +1,65 @@
+package optimization
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	prevCosts := []float64{5, 1, -3, 4, 2}
+	newCosts := []float64{5, 6, -2, 1, 2}
+	prevSel, _ := CostOptimization(prevCosts) // -3, 1, 2
+	newSel, _ := CostOptimization(newCosts)   // -2, 1, 2
+
+	d, err := Diff(prevCosts, prevSel, newCosts, newSel)
+	if err != nil {
+		t.Fatalf("Diff returned unexpected error: %v", err)
+	}
+	if !slices.Equal(d.Added, []int{3}) || !slices.Equal(d.Removed, []int{1}) || !slices.Equal(d.Kept, []int{2, 4}) {
+		t.Fatalf("added %v removed %v kept %v", d.Added, d.Removed, d.Kept)
+	}
+	if d.PrevTotal != 0 || d.NewTotal != 1 {
+		t.Fatalf("totals %v -> %v, expected 0 -> 1", d.PrevTotal, d.NewTotal)
+	}
+	// Yesterday's selection at today's prices: 6 - 2 + 2 = 6.
+	if d.PriceDelta != 6 || d.SelectionDelta != -5 {
+		t.Fatalf("price delta %v, selection delta %v, expected 6 and -5", d.PriceDelta, d.SelectionDelta)
+	}
+	if !d.Changed() {
+		t.Fatal("Changed reported false for a changed selection")
+	}
+
+	want := "1 added, 1 removed, 2 kept; total 0 -> 1 (+1: prices +6, selection -5)"
+	if got := d.Summary(); got != want {
+		t.Fatalf("Summary() = %q, expected %q", got, want)
+	}
+}
+
+func TestDiffUnchanged(t *testing.T) {
+	costs := []float64{3, -1, 2}
+	sel, _ := CostOptimization(costs)
+	d, err := Diff(costs, sel, costs, sel)
+	if err != nil {
+		t.Fatalf("Diff returned unexpected error: %v", err)
+	}
+	if d.Changed() || d.PriceDelta != 0 || d.SelectionDelta != 0 || len(d.Kept) != 2 {
+		t.Fatalf("unexpected diff of identical runs: %+v", d)
+	}
+}
+
+func TestDiffErrors(t *testing.T) {
+	if _, err := Diff([]float64{1, 2}, []int{1, 0}, []float64{1}, []int{1}); !errors.Is(err, ErrDifferentSizes) {
+		t.Fatalf("expected ErrDifferentSizes, got %v", err)
+	}
+	if _, err := Diff([]float64{1}, []int{1}, []float64{math.NaN()}, []int{1}); !errors.Is(err, ErrInvalidNumber) {
+		t.Fatalf("expected ErrInvalidNumber, got %v", err)
+	}
+	if _, err := Diff([]float64{1, 2}, []int{2, 0}, []float64{1, 2}, []int{1, 0}); !errors.Is(err, ErrInvalidSelection) {
+		t.Fatalf("expected ErrInvalidSelection, got %v", err)
+	}
+	if _, err := Diff([]float64{1, 2}, []int{1, 0}, []float64{1, 2}, []int{1, -1}); !errors.Is(err, ErrInvalidSelection) {
+		t.Fatalf("expected ErrInvalidSelection, got %v", err)
+	}
+}
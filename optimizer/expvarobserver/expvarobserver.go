@@ -0,0 +1,74 @@
+// Package expvarobserver provides an optimization.Observer that publishes cumulative optimizer
+// metrics through the standard expvar package, so they show up on /debug/vars.
+package expvarobserver
+
+import (
+	"expvar"
+
+	optimization "github.com/greyskp/cost_optimization/optimizer"
+)
+
+// DefaultName is a conventional name for the published map.
+const DefaultName = "cost_optimization"
+
+// Observer updates an expvar.Map with:
+//
+//	calls         number of calls
+//	errors        number of failed calls
+//	elements      total number of costs processed
+//	replacements  total heap replacements
+//	latency_ns    count, min, max, mean, p50, p95 and p99 of the call durations, in nanoseconds
+type Observer struct {
+	vars         *expvar.Map
+	calls        expvar.Int
+	errors       expvar.Int
+	elements     expvar.Int
+	replacements expvar.Int
+	latency      *optimization.HistogramObserver
+}
+
+// New publishes the metrics under name. If a map is already published under that name it is
+// reused (its metric keys are replaced); like expvar.Publish, New panics if name holds another kind of variable.
+func New(name string) *Observer {
+	vars, ok := expvar.Get(name).(*expvar.Map)
+	if !ok {
+		vars = expvar.NewMap(name)
+	}
+
+	o := &Observer{vars: vars, latency: optimization.NewHistogramObserver()}
+	vars.Set("calls", &o.calls)
+	vars.Set("errors", &o.errors)
+	vars.Set("elements", &o.elements)
+	vars.Set("replacements", &o.replacements)
+	vars.Set("latency_ns", expvar.Func(o.latencySummary))
+	return o
+}
+
+// Observe records one call.
+func (o *Observer) Observe(s optimization.Stats) {
+	o.calls.Add(1)
+	if s.Err != nil {
+		o.errors.Add(1)
+	}
+	o.elements.Add(int64(s.N))
+	o.replacements.Add(int64(s.Replacements))
+	o.latency.Observe(optimization.Stats{Duration: s.Duration})
+}
+
+// Map returns the published map.
+func (o *Observer) Map() *expvar.Map {
+	return o.vars
+}
+
+func (o *Observer) latencySummary() any {
+	d := o.latency.Snapshot().Duration
+	return map[string]any{
+		"count": d.Count,
+		"min":   d.Min,
+		"max":   d.Max,
+		"mean":  d.Mean,
+		"p50":   d.P50,
+		"p95":   d.P95,
+		"p99":   d.P99,
+	}
+}
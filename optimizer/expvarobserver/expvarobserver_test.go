@@ -0,0 +1,51 @@
+package expvarobserver
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+
+	optimization "github.com/greyskp/cost_optimization/optimizer"
+)
+
+func TestPublishedMetrics(t *testing.T) {
+	o := New("expvarobserver_test")
+
+	if _, err := optimization.CostOptimization([]float64{3, 1, 2, 5}, optimization.WithObserver(o)); err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	optimization.CostOptimization(nil, optimization.WithObserver(o))
+	o.Observe(optimization.Stats{N: 10, Replacements: 4, Duration: time.Millisecond})
+
+	var vars struct {
+		Calls        int64 `json:"calls"`
+		Errors       int64 `json:"errors"`
+		Elements     int64 `json:"elements"`
+		Replacements int64 `json:"replacements"`
+		Latency      struct {
+			Count uint64 `json:"count"`
+			Max   uint64 `json:"max"`
+		} `json:"latency_ns"`
+	}
+	published := expvar.Get("expvarobserver_test")
+	if published == nil {
+		t.Fatalf("map was not published")
+	}
+	if err := json.Unmarshal([]byte(published.String()), &vars); err != nil {
+		t.Fatalf("published map is not valid JSON: %v", err)
+	}
+
+	if vars.Calls != 3 || vars.Errors != 1 || vars.Elements != 14 || vars.Replacements != 5 {
+		t.Fatalf("got %+v, expected calls=3 errors=1 elements=14 replacements=5", vars)
+	}
+	if vars.Latency.Count != 3 || vars.Latency.Max < uint64(time.Millisecond) {
+		t.Fatalf("got latency %+v, expected 3 calls with max >= 1ms", vars.Latency)
+	}
+
+	// Re-creating the observer reuses the published map instead of panicking.
+	again := New("expvarobserver_test")
+	if again.Map() != o.Map() {
+		t.Fatalf("New did not reuse the published map")
+	}
+}
@@ -0,0 +1,75 @@
+package optimization
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBucketBounds(t *testing.T) {
+	for _, v := range []uint64{0, 1, 127, 128, 129, 255, 256, 1000, 123456789, math.MaxUint64} {
+		i := bucketIndex(v)
+		if upper := bucketUpper(i); upper < v {
+			t.Fatalf("value %d maps to bucket %d whose upper bound %d is below it", v, i, upper)
+		}
+		if i > 0 && bucketUpper(i-1) >= v {
+			t.Fatalf("value %d also fits the previous bucket %d", v, i-1)
+		}
+		if upper := bucketUpper(i); float64(upper-v) > float64(v)/histogramSubBuckets {
+			t.Fatalf("bucket of %d is too wide: upper bound %d", v, upper)
+		}
+	}
+}
+
+func TestHistogramObserverPercentiles(t *testing.T) {
+	h := NewHistogramObserver()
+
+	// 1..1000 microseconds recorded from several goroutines.
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := w + 1; i <= 1000; i += 4 {
+				h.Observe(Stats{N: i, Replacements: i % 10, Duration: time.Duration(i) * time.Microsecond})
+			}
+		}()
+	}
+	wg.Wait()
+	h.Observe(Stats{Err: ErrEmptyInput})
+
+	s := h.Snapshot()
+	if s.Errors != 1 || s.Duration.Count != 1001 {
+		t.Fatalf("got errors=%d count=%d, expected 1 and 1001", s.Errors, s.Duration.Count)
+	}
+	if s.N.Min != 0 || s.N.Max != 1000 {
+		t.Fatalf("got N min=%d max=%d, expected 0 and 1000", s.N.Min, s.N.Max)
+	}
+
+	checks := []struct {
+		name     string
+		got      uint64
+		expected float64
+	}{
+		{"duration p50", s.Duration.P50, 500e3},
+		{"duration p95", s.Duration.P95, 950e3},
+		{"duration p99", s.Duration.P99, 990e3},
+		{"n p50", s.N.P50, 500},
+		{"n p99", s.N.P99, 990},
+	}
+	for _, c := range checks {
+		if math.Abs(float64(c.got)-c.expected) > c.expected*0.01 {
+			t.Fatalf("%s is %d, expected %v within 1%%", c.name, c.got, c.expected)
+		}
+	}
+	if s.Replacements.Max != 9 || s.Replacements.P50 != 4 {
+		t.Fatalf("got replacements max=%d p50=%d, expected 9 and 4", s.Replacements.Max, s.Replacements.P50)
+	}
+}
+
+func TestHistogramObserverEmpty(t *testing.T) {
+	if s := NewHistogramObserver().Snapshot(); s.Duration != (Distribution{}) {
+		t.Fatalf("empty snapshot got %+v", s.Duration)
+	}
+}
@@ -0,0 +1,89 @@
+package optimization
+
+// CostOptimizationInt64 is CostOptimization for integer costs, e.g. amounts in cents.
+// Instead of a heap, the cutoff among the non-negative costs is found with radix partitioning,
+// which runs in O(n). The output and tie-breaking (lower indices first) match CostOptimization.
+func CostOptimizationInt64(prices []int64, opts ...Option) (_ []int, err error) {
+	cfg := applyOptions(opts)
+	track := cfg.beginCall("optimization.CostOptimizationInt64", len(prices))
+	stats := Stats{N: len(prices)}
+	defer track.finish(&stats, &err)
+
+	if err = cfg.supports(0); err != nil {
+		return nil, err
+	}
+
+	if len(prices) == 0 {
+		return nil, ErrEmptyInput
+	}
+
+	minSize := requiredCount(len(prices))
+	res := make([]int, len(prices))
+
+	// The totals are only worth computing when someone is listening.
+	if _, silent := cfg.observer.(NoOpObserver); !silent {
+		defer func() {
+			stats.CutoffCost, stats.TotalCost = int64Totals(prices, res)
+		}()
+	}
+
+	// Integer costs need no validation, so the first pass only marks the negatives.
+	for i, value := range prices {
+		if i > 0 && i%progressInterval == 0 {
+			track.progress(PhaseNegativeScan, i, len(prices))
+		}
+		if value < 0 {
+			res[i] = 1
+			stats.Negatives++
+		}
+	}
+	stats.SelectedCount = stats.Negatives
+	stats.NegativeScanDuration = track.lap(PhaseNegativeScan)
+
+	if stats.SelectedCount >= minSize {
+		stats.Path = PathNegatives
+		return res, nil
+	}
+	stats.LeftToFill = minSize - stats.SelectedCount
+	stats.Path = PathRadix
+
+	keys := make([]uint64, 0, len(prices)-stats.SelectedCount)
+	for _, value := range prices {
+		if value >= 0 {
+			keys = append(keys, uint64(value))
+		}
+	}
+	cutoff, below := radixSelectKeys(keys, stats.LeftToFill)
+
+	// Everything below the cutoff is selected, then the earliest costs equal to it.
+	ties := stats.LeftToFill - below
+	for i, value := range prices {
+		if value < 0 {
+			continue
+		}
+		key := uint64(value)
+		if key < cutoff || (key == cutoff && ties > 0) {
+			if key == cutoff {
+				ties--
+			}
+			res[i] = 1
+			stats.SelectedCount++
+		}
+	}
+	stats.FillDuration = track.lap(PhaseFill)
+
+	return res, nil
+}
+
+// int64Totals is selectionTotals for integer costs.
+func int64Totals(prices []int64, res []int) (float64, float64) {
+	var cutoff int64
+	total := 0.0
+	for i, value := range prices {
+		if res[i] == 1 {
+			cutoff = max(cutoff, value)
+			total += float64(value)
+		}
+	}
+	return float64(cutoff), total
+}
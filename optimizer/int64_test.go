@@ -0,0 +1,60 @@
+package optimization
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestInt64MatchesCostOptimization(t *testing.T) {
+	inputs := [][]int64{
+		{-10, 17, 15, -40, 20},
+		{0, 0, 0, 0, 0},
+		{987, 684, 6450, 4156, 8},
+		{-987, -684, -6450, -4156, -8},
+		{math.MaxInt64, 0, math.MinInt64, 1 << 40, 1 << 40, 3},
+		randInt64s(-10000, 50000, 1001),
+		randInt64s(0, 5, 500),
+		randInt64s(0, 1<<50, 2000),
+	}
+
+	for _, costs := range inputs {
+		floats := make([]float64, len(costs))
+		for i, c := range costs {
+			floats[i] = float64(c)
+		}
+		var expectedStats, stats statsRecorder
+		expected, err := CostOptimization(floats, WithObserver(&expectedStats))
+		if err != nil {
+			t.Fatalf("CostOptimization returned unexpected error: %v", err)
+		}
+
+		result, err := CostOptimizationInt64(costs, WithObserver(&stats))
+		if err != nil {
+			t.Fatalf("CostOptimizationInt64 returned unexpected error: %v", err)
+		}
+		if !slices.Equal(result, expected) {
+			t.Fatalf("CostOptimizationInt64(%v) got %v, expected %v", costs, result, expected)
+		}
+		if stats.last.CutoffCost != expectedStats.last.CutoffCost || stats.last.TotalCost != expectedStats.last.TotalCost {
+			t.Fatalf("got cutoff=%v total=%v, expected %v and %v", stats.last.CutoffCost, stats.last.TotalCost,
+				expectedStats.last.CutoffCost, expectedStats.last.TotalCost)
+		}
+	}
+}
+
+func TestInt64Empty(t *testing.T) {
+	result, err := CostOptimizationInt64([]int64{})
+	if result != nil || err != ErrEmptyInput {
+		t.Fatalf("empty input got %v, %v, expected %v", result, err, ErrEmptyInput)
+	}
+}
+
+func randInt64s(min, max int64, n int) []int64 {
+	res := make([]int64, n)
+	for i := range res {
+		res[i] = min + rand.Int64N(max-min+1)
+	}
+	return res
+}
@@ -0,0 +1,91 @@
+package optimization
+
+import (
+	"iter"
+	"math"
+)
+
+// CostOptimizationSeq applies the CostOptimization rule to a sequence of costs and returns the
+// selected indices in increasing order. The sequence is iterated twice up front and once more each
+// time the result is ranged over, so it must yield the same values every time; only the costs that
+// still compete for the remaining slots are held in memory.
+func CostOptimizationSeq(costs iter.Seq[float64], opts ...Option) (iter.Seq[int], error) {
+	return CostOptimizationSeq2(enumerate(costs), opts...)
+}
+
+// CostOptimizationSeq2 is CostOptimizationSeq for sequences that carry their own indices. Indices
+// must be unique; they are used for tie-breaking and are yielded in the order the sequence produces them.
+func CostOptimizationSeq2(costs iter.Seq2[int, float64], opts ...Option) (_ iter.Seq[int], err error) {
+	cfg := applyOptions(opts)
+	// The number of costs is only known once the sequence has been ranged over.
+	track := cfg.beginCall("optimization.CostOptimizationSeq", -1)
+	var stats Stats
+	defer track.finish(&stats, &err)
+
+	if err = cfg.supports(0); err != nil {
+		return nil, err
+	}
+
+	for _, value := range costs {
+		if math.IsNaN(value) {
+			stats.ValidateDuration = track.lap(PhaseValidate)
+			return nil, ErrInvalidNumber
+		}
+		stats.N++
+		if stats.N%progressInterval == 0 {
+			track.progress(PhaseValidate, stats.N, -1)
+		}
+		if value < 0 {
+			stats.Negatives++
+		}
+	}
+	stats.ValidateDuration = track.lap(PhaseValidate)
+	if stats.N == 0 {
+		return nil, ErrEmptyInput
+	}
+
+	stats.SelectedCount = stats.Negatives
+	stats.LeftToFill = max(requiredCount(stats.N)-stats.Negatives, 0)
+	stats.Path = PathNegatives
+
+	var cutoff Cutoff
+	if stats.LeftToFill > 0 {
+		stats.Path = PathHeap
+		smallest := NewBoundedTopK(stats.LeftToFill, lessCost)
+		for index, value := range costs {
+			if value < 0 {
+				continue
+			}
+			full := smallest.Full()
+			if smallest.Offer(cost{value, index}) && full {
+				stats.Replacements++
+			}
+		}
+
+		highest, _ := smallest.Worst()
+		cutoff = Cutoff{Price: highest.price, Index: highest.index, Fill: smallest.Len()}
+		stats.SelectedCount += smallest.Len()
+	}
+	stats.FillDuration = track.lap(PhaseFill)
+
+	return func(yield func(int) bool) {
+		for index, value := range costs {
+			if cutoff.Selected(value, index) && !yield(index) {
+				return
+			}
+		}
+	}, nil
+}
+
+// enumerate pairs every value of seq with its position.
+func enumerate(seq iter.Seq[float64]) iter.Seq2[int, float64] {
+	return func(yield func(int, float64) bool) {
+		index := 0
+		for value := range seq {
+			if !yield(index, value) {
+				return
+			}
+			index++
+		}
+	}
+}
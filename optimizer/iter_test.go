@@ -0,0 +1,68 @@
+package optimization
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestSeqMatchesCostOptimization(t *testing.T) {
+	inputs := [][]float64{
+		{-10, 17, 15, -40, 20},
+		{0.0, 0, 0.0, 0, 0},
+		randFloats(-100.0, 500.0, 501),
+		randFloats(-500.0, -1.0, 40),
+	}
+
+	for _, costs := range inputs {
+		expected, err := CostOptimization(costs)
+		if err != nil {
+			t.Fatalf("CostOptimization returned unexpected error: %v", err)
+		}
+		var expectedIndices []int
+		for i, v := range expected {
+			if v == 1 {
+				expectedIndices = append(expectedIndices, i)
+			}
+		}
+
+		seq, err := CostOptimizationSeq(slices.Values(costs))
+		if err != nil {
+			t.Fatalf("CostOptimizationSeq returned unexpected error: %v", err)
+		}
+		if got := slices.Collect(seq); !slices.Equal(got, expectedIndices) {
+			t.Fatalf("CostOptimizationSeq got %v, expected %v", got, expectedIndices)
+		}
+
+		seq, err = CostOptimizationSeq2(slices.All(costs))
+		if err != nil {
+			t.Fatalf("CostOptimizationSeq2 returned unexpected error: %v", err)
+		}
+		if got := slices.Collect(seq); !slices.Equal(got, expectedIndices) {
+			t.Fatalf("CostOptimizationSeq2 got %v, expected %v", got, expectedIndices)
+		}
+	}
+}
+
+func TestSeq2CustomIndices(t *testing.T) {
+	// Keys drive tie-breaking: the equal costs at 7 and 3 are resolved in favour of 3.
+	costs := map[int]float64{7: 5, 3: 5, 10: 9}
+	seq, err := CostOptimizationSeq2(maps.All(costs))
+	if err != nil {
+		t.Fatalf("CostOptimizationSeq2 returned unexpected error: %v", err)
+	}
+	got := slices.Sorted(seq)
+	if !slices.Equal(got, []int{3, 7}) {
+		t.Fatalf("CostOptimizationSeq2 got %v, expected [3 7]", got)
+	}
+}
+
+func TestSeqErrors(t *testing.T) {
+	if _, err := CostOptimizationSeq(slices.Values([]float64{})); err != ErrEmptyInput {
+		t.Fatalf("empty sequence got %v, expected %v", err, ErrEmptyInput)
+	}
+	if _, err := CostOptimizationSeq(slices.Values([]float64{1, math.NaN()})); err != ErrInvalidNumber {
+		t.Fatalf("NaN sequence got %v, expected %v", err, ErrInvalidNumber)
+	}
+}
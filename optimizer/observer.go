@@ -2,13 +2,45 @@ package optimization
 
 import "time"
 
+// Path identifies how a call reached its selection. When a Solver does the selection, the Path is
+// the solver name (PathHeap for the default solver).
+type Path string
+
+const (
+	PathNegatives        Path = "negatives"         // enough negative costs, nothing else to select
+	PathHeap             Path = "heap"              // smallest remaining costs tracked in a bounded max-heap
+	PathSortedAscending  Path = "sorted_ascending"  // non-decreasing input, selection taken directly
+	PathSortedDescending Path = "sorted_descending" // non-increasing input, selection taken directly
+	PathRadix            Path = "radix"             // cutoff found with radix counting passes
+	PathApprox           Path = "approx"            // cutoff estimated from a sample, selection not guaranteed optimal
+)
+
 // Stats is a lightweight, backend-agnostic payload that callers can export to logs/metrics/traces however they want.
+// Fields beyond Duration are only filled by the entry points that can compute them; zero otherwise.
 type Stats struct {
 	N             int
 	SelectedCount int
 	LeftToFill    int
 	Replacements  int
 	Duration      time.Duration
+	Path          Path
+	Solver        string // name of the Solver that ran, empty when no solver was needed
+	Negatives     int
+	CutoffCost    float64 // largest non-negative cost selected, 0 when only negatives were
+	TotalCost     float64 // as computed by TotalCost
+	Err           error   // error returned to the caller, nil on success
+
+	// Set only with WithPreviousSelection. The selection is then made on the penalty-adjusted
+	// costs, which Negatives, LeftToFill and Path describe; CutoffCost and TotalCost use the
+	// original costs.
+	Switches        int // items whose flag differs from the previous selection
+	SwitchesAvoided int // how many fewer switches than a plain CostOptimization run
+
+	// Per-phase breakdown of Duration.
+	ValidateDuration     time.Duration // NaN checks, negative count and monotonicity detection
+	NegativeScanDuration time.Duration // marking the negative costs
+	FillDuration         time.Duration // heap fill, solver or sorted fast path
+	OutputDuration       time.Duration // cutoff and total cost for these stats
 }
 
 // Observer is an optional hook. Implementations should be fast and non-blocking
@@ -16,6 +48,162 @@ type Observer interface {
 	Observe(stats Stats)
 }
 
+// Phase names a step of a call, in the order they run.
+type Phase string
+
+const (
+	PhaseValidate     Phase = "validate"
+	PhaseNegativeScan Phase = "negative_scan"
+	PhaseFill         Phase = "fill"
+	PhaseOutput       Phase = "output"
+)
+
+// progressInterval is how many elements are scanned between two OnProgress events.
+const progressInterval = 1 << 16
+
+// LifecycleObserver is an optional extension of Observer, detected by type assertion, that is told
+// about a call of any entry point while it runs. OnStart comes first (n is -1 when the input size
+// is only known once it has been read), then OnPhase when each phase ends (with OnProgress events
+// inside long phases, whose total is -1 when unknown), then OnFinish with the same Stats that are
+// passed to Observe right after it. An entry point only reports the phases it has: the Int64
+// variant has nothing to validate, for instance. Calls happen on the caller's goroutine.
+type LifecycleObserver interface {
+	Observer
+	OnStart(n int)
+	OnPhase(phase Phase, elapsed time.Duration)
+	OnProgress(phase Phase, done, total int)
+	OnFinish(stats Stats)
+}
+
+// ObserverFunc adapts a plain function to Observer.
+type ObserverFunc func(stats Stats)
+
+func (f ObserverFunc) Observe(stats Stats) { f(stats) }
+
+// MultiObserver returns an Observer that forwards every event to each of observers, in order.
+// Lifecycle events are forwarded to the observers that implement LifecycleObserver.
+func MultiObserver(observers ...Observer) Observer {
+	var m multiObserver
+	for _, o := range observers {
+		if o != nil {
+			m = append(m, o)
+		}
+	}
+	return m
+}
+
+type multiObserver []Observer
+
+func (m multiObserver) Observe(stats Stats) {
+	for _, o := range m {
+		o.Observe(stats)
+	}
+}
+
+func (m multiObserver) OnStart(n int) {
+	for _, o := range m {
+		if lc, ok := o.(LifecycleObserver); ok {
+			lc.OnStart(n)
+		}
+	}
+}
+
+func (m multiObserver) OnPhase(phase Phase, elapsed time.Duration) {
+	for _, o := range m {
+		if lc, ok := o.(LifecycleObserver); ok {
+			lc.OnPhase(phase, elapsed)
+		}
+	}
+}
+
+func (m multiObserver) OnProgress(phase Phase, done, total int) {
+	for _, o := range m {
+		if lc, ok := o.(LifecycleObserver); ok {
+			lc.OnProgress(phase, done, total)
+		}
+	}
+}
+
+func (m multiObserver) OnFinish(stats Stats) {
+	for _, o := range m {
+		if lc, ok := o.(LifecycleObserver); ok {
+			lc.OnFinish(stats)
+		}
+	}
+}
+
+// call reports one entry-point call to the configured observer: OnStart when it begins, its phases
+// through the embedded phaseClock, and OnFinish then Observe once it returns, however it returns.
+type call struct {
+	phaseClock
+	observer Observer
+	start    time.Time
+}
+
+// beginCall opens the span named name and tells a lifecycle observer that a call over n costs
+// started. It must be called right after applyOptions.
+func (o *options) beginCall(name string, n int) call {
+	o.startSpan(name)
+	c := call{observer: o.observer}
+	c.lifecycle, _ = o.observer.(LifecycleObserver)
+	// Nobody reads the timings of a call that is neither observed nor traced.
+	_, silent := o.observer.(NoOpObserver)
+	c.timed = c.lifecycle != nil || !silent
+	if c.timed {
+		c.start = time.Now()
+		c.last = c.start
+	}
+	if c.lifecycle != nil {
+		c.lifecycle.OnStart(n)
+	}
+	return c
+}
+
+// finish fills in the duration and error of stats and reports them. It is deferred with pointers to
+// the entry point's Stats and named error result, so both are read when the call returns.
+func (c *call) finish(stats *Stats, err *error) {
+	if c.timed {
+		stats.Duration = time.Since(c.start)
+	}
+	stats.Err = *err
+	if c.lifecycle != nil {
+		c.lifecycle.OnFinish(*stats)
+	}
+	c.observer.Observe(*stats)
+}
+
+// phaseClock measures consecutive phases of a call and reports them to a lifecycle observer, if any.
+// When timed is false it measures nothing and every lap is zero.
+type phaseClock struct {
+	last      time.Time
+	lifecycle LifecycleObserver
+	timed     bool
+}
+
+// lap returns the time elapsed since the previous lap (or the start) and starts a new one.
+func (c *phaseClock) lap(phase Phase) time.Duration {
+	if !c.timed {
+		return 0
+	}
+	now := time.Now()
+	d := now.Sub(c.last)
+	c.last = now
+	if c.lifecycle != nil {
+		c.lifecycle.OnPhase(phase, d)
+	}
+	return d
+}
+
+// progress reports that done of total elements of phase were processed.
+func (c *phaseClock) progress(phase Phase, done, total int) {
+	if c.lifecycle != nil {
+		c.lifecycle.OnProgress(phase, done, total)
+	}
+}
+
+// observed reports whether a lifecycle observer listens to the call's phases and progress.
+func (c *phaseClock) observed() bool { return c.lifecycle != nil }
+
 // NoOpObserver is the default when no observer is provided.
 type NoOpObserver struct{}
 
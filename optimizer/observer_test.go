@@ -0,0 +1,139 @@
+package optimization
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type eventRecorder struct {
+	events []string
+}
+
+func (r *eventRecorder) Observe(s Stats) { r.events = append(r.events, "observe") }
+func (r *eventRecorder) OnStart(n int)   { r.events = append(r.events, fmt.Sprintf("start %d", n)) }
+func (r *eventRecorder) OnPhase(p Phase, _ time.Duration) {
+	r.events = append(r.events, "phase "+string(p))
+}
+func (r *eventRecorder) OnProgress(p Phase, done, total int) {
+	r.events = append(r.events, fmt.Sprintf("progress %s %d/%d", p, done, total))
+}
+func (r *eventRecorder) OnFinish(s Stats) { r.events = append(r.events, "finish") }
+
+func TestLifecycleEvents(t *testing.T) {
+	var rec eventRecorder
+	if _, err := CostOptimization([]float64{3, 1, 2, 5}, WithObserver(&rec)); err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	expected := []string{"start 4", "phase validate", "phase negative_scan", "phase fill", "phase output", "finish", "observe"}
+	if !slices.Equal(rec.events, expected) {
+		t.Fatalf("got events %v, expected %v", rec.events, expected)
+	}
+
+	rec = eventRecorder{}
+	CostOptimization([]float64{1, 0, math.NaN()}, WithObserver(&rec))
+	expected = []string{"start 3", "phase validate", "finish", "observe"}
+	if !slices.Equal(rec.events, expected) {
+		t.Fatalf("got events %v, expected %v", rec.events, expected)
+	}
+}
+
+func TestLifecycleEntryPoints(t *testing.T) {
+	costs := []float64{3, 1, 2, 5}
+	tests := []struct {
+		name     string
+		run      func(Observer) error
+		expected []string
+	}{
+		{"Stream", func(obs Observer) error {
+			return CostOptimizationStream(strings.NewReader("3 1 2 5"), io.Discard, FormatText, WithObserver(obs))
+		}, []string{"start -1", "phase validate", "phase fill", "phase output", "finish", "observe"}},
+		{"Seq", func(obs Observer) error {
+			_, err := CostOptimizationSeq(slices.Values(costs), WithObserver(obs))
+			return err
+		}, []string{"start -1", "phase validate", "phase fill", "finish", "observe"}},
+		{"Int64", func(obs Observer) error {
+			_, err := CostOptimizationInt64([]int64{3, 1, 2, 5}, WithObserver(obs))
+			return err
+		}, []string{"start 4", "phase negative_scan", "phase fill", "finish", "observe"}},
+		{"Approx", func(obs Observer) error {
+			_, err := CostOptimizationApprox(costs, WithObserver(obs))
+			return err
+		}, []string{"start 4", "phase validate", "phase fill", "finish", "observe"}},
+		{"Window", func(obs Observer) error {
+			_, err := CostOptimizationWindow(costs, WithObserver(obs))
+			return err
+		}, []string{"start 4", "phase validate", "phase fill", "phase output", "finish", "observe"}},
+		{"Plan", func(obs Observer) error {
+			_, err := PlanPeriods([][]float64{{3, 1}, {2, 5}}, []Transition{{}, {}}, WithObserver(obs))
+			return err
+		}, []string{"start 4", "phase validate", "phase fill", "phase output", "finish", "observe"}},
+		{"PlanInvalid", func(obs Observer) error {
+			PlanPeriods([][]float64{{3, math.NaN()}}, []Transition{{}, {}}, WithObserver(obs))
+			return nil
+		}, []string{"start 2", "phase validate", "finish", "observe"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rec eventRecorder
+			if err := tt.run(&rec); err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if !slices.Equal(rec.events, tt.expected) {
+				t.Fatalf("got events %v, expected %v", rec.events, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLifecycleProgress(t *testing.T) {
+	var rec eventRecorder
+	n := 3*progressInterval + 10
+	if _, err := CostOptimization(randFloats(0.0, 1.0, n), WithObserver(&rec)); err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+
+	var progress []string
+	for _, e := range rec.events {
+		if len(e) > 8 && e[:8] == "progress" {
+			progress = append(progress, e)
+		}
+	}
+	expected := []string{
+		fmt.Sprintf("progress validate %d/%d", progressInterval, n),
+		fmt.Sprintf("progress validate %d/%d", 2*progressInterval, n),
+		fmt.Sprintf("progress validate %d/%d", 3*progressInterval, n),
+		fmt.Sprintf("progress fill %d/%d", progressInterval, n),
+		fmt.Sprintf("progress fill %d/%d", 2*progressInterval, n),
+		fmt.Sprintf("progress fill %d/%d", 3*progressInterval, n),
+	}
+	if !slices.Equal(progress, expected) {
+		t.Fatalf("got progress %v, expected %v", progress, expected)
+	}
+}
+
+func TestMultiObserver(t *testing.T) {
+	var rec eventRecorder
+	var plain statsRecorder
+	var fromFunc Stats
+
+	obs := MultiObserver(&rec, nil, &plain, ObserverFunc(func(s Stats) { fromFunc = s }))
+	if _, err := CostOptimization([]float64{-1, 4, 2}, WithObserver(obs)); err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+
+	if len(rec.events) != 7 {
+		t.Fatalf("lifecycle observer got %d events, expected 7: %v", len(rec.events), rec.events)
+	}
+	if plain.calls != 1 || plain.last.N != 3 {
+		t.Fatalf("plain observer got %d calls with N=%d, expected 1 call with N=3", plain.calls, plain.last.N)
+	}
+	if fromFunc.SelectedCount != 2 {
+		t.Fatalf("ObserverFunc got SelectedCount=%d, expected 2", fromFunc.SelectedCount)
+	}
+}
@@ -1,10 +1,8 @@
 package optimization
 
 import (
-	"container/heap"
 	"errors"
 	"math"
-	"time"
 )
 
 var ErrEmptyInput = errors.New("input costs slice is empty")
@@ -16,6 +14,9 @@ type cost struct {
 	index int
 }
 
+// MaxHeap is the container/heap based max-heap formerly used by CostOptimization.
+//
+// Deprecated: use BoundedTopK, which is generic and does not allocate per element.
 type MaxHeap []cost
 
 func (h MaxHeap) Len() int { return len(h) }
@@ -34,92 +35,230 @@ func (h *MaxHeap) Pop() any {
 	return x
 }
 
-// CostOptimization returns a binary slice indicating which prices should be selected to minimize total cost ensuring at least half of the input prices are selected, prioritizing negative values and the smallest positive values.
-func CostOptimization(prices []float64, opts ...Option) ([]int, error) {
+// compareCost orders costs by price, then by index.
+func compareCost(a, b cost) int {
+	switch {
+	case a.price < b.price:
+		return -1
+	case a.price > b.price:
+		return 1
+	}
+	return a.index - b.index
+}
 
-	cfg := applyOptions(opts)
+// lessCost reports whether a orders before b under compareCost.
+func lessCost(a, b cost) bool {
+	return compareCost(a, b) < 0
+}
 
-	start := time.Now()
-	var selectedCount int
-	var leftToFill int
-	var replacements int
+// CostOptimization returns a binary slice indicating which prices should be selected to minimize total cost ensuring at least half of the input prices are selected, prioritizing negative values and the smallest positive values.
+func CostOptimization(prices []float64, opts ...Option) (out []int, err error) {
+	cfg := applyOptions(opts)
+	track := cfg.beginCall("optimization.CostOptimization", len(prices))
+	stats := Stats{N: len(prices)}
 
-	// Ensure we always emit stats once, even on early returns/errors.
+	// The recording and the audit record follow the observer, so they are deferred first.
 	defer func() {
-		cfg.observer.Observe(Stats{
-			N:             len(prices),
-			SelectedCount: selectedCount,
-			LeftToFill:    leftToFill,
-			Replacements:  replacements,
-			Duration:      time.Since(start),
-		})
+		if cfg.recorder != nil {
+			cfg.recorder.record(prices, cfg, out, err)
+		}
+		if cfg.audit != nil && err == nil {
+			*cfg.audit = newAuditRecord(prices, cfg, out)
+		}
 	}()
+	// Ensure we always emit stats once, even on early returns/errors.
+	defer track.finish(&stats, &err)
+
+	// Every option but WithCircularWindow applies to a plain selection.
+	if err = cfg.supports(^featureCircular); err != nil {
+		return nil, err
+	}
+	if cfg.audit != nil && len(cfg.auditKey) == 0 {
+		return nil, ErrAuditKey
+	}
 
 	if len(prices) == 0 {
 		return nil, ErrEmptyInput
 	}
 
-	// Number of elements to be added to reach at least n/2
-	minSize := 0
-	if len(prices)%2 == 0 {
-		minSize = len(prices) / 2
-	} else {
-		minSize = len(prices)/2 + 1
+	// With a previous selection, the switching penalties are folded into the costs.
+	work := prices
+	if cfg.previous != nil {
+		if work, err = stableCosts(prices, cfg.previous, cfg.penalty); err != nil {
+			return nil, err
+		}
 	}
 
-	res := make([]int, len(prices))
-	//count := 0
+	// Number of elements to be added to reach at least n/2
+	minSize := requiredCount(len(prices))
 
-	for i, value := range prices {
-		if math.IsNaN(value) {
-			return nil, ErrInvalidNumber
+	// Validate and count the negatives, tracking monotonicity so sorted feeds can skip the heap.
+	// The counters stay local: stats escapes to the deferred finish, so its fields live in memory.
+	ascending, descending := true, true
+	negatives := 0
+
+	// Progress is reported between chunks, so the per-element loop carries no observer check.
+	for start := 0; start < len(work); start += progressInterval {
+		if start > 0 {
+			track.progress(PhaseValidate, start, len(prices))
+		}
+		for _, value := range work[start:min(start+progressInterval, len(work))] {
+			if math.IsNaN(value) {
+				stats.ValidateDuration = track.lap(PhaseValidate)
+				return nil, ErrInvalidNumber
+			}
+			if value < 0 {
+				negatives++
+			}
 		}
-		if value < 0 {
-			res[i] = 1
-			selectedCount++
+		// Unsorted input stops paying for the comparisons after its first inversion either way.
+		for i := max(start, 1); i < min(start+progressInterval, len(work)) && (ascending || descending); i++ {
+			if work[i] < work[i-1] {
+				ascending = false
+			} else if work[i] > work[i-1] {
+				descending = false
+			}
 		}
 	}
+	stats.Negatives = negatives
+	stats.ValidateDuration = track.lap(PhaseValidate)
 
-	// If there is enough negative costs return only those
-	if selectedCount >= minSize {
-		leftToFill = 0
-		return res, nil
+	res := make([]int, len(prices))
+	if stats.Negatives > 0 {
+		for i, value := range work {
+			if value < 0 {
+				res[i] = 1
+			}
+		}
 	}
+	stats.SelectedCount = stats.Negatives
+	stats.NegativeScanDuration = track.lap(PhaseNegativeScan)
 
-	// Initialize the heap used to keep track of the highest element
-	smallest := &MaxHeap{}
-	heap.Init(smallest)
-	leftToFill = minSize - selectedCount
-
-	for index, value := range prices {
-		// fill with the first values available
-		if res[index] == 0 {
-			c := cost{value, index}
+	if cfg.sequenceConstrained(len(prices)) {
+		stats.Path = PathSequence
+		if cfg.itemConstrained() {
+			stats.FillDuration = track.lap(PhaseFill)
+			return nil, ErrConflictingOptions
+		}
+		if err = selectSequence(work, res, cfg); err != nil {
+			stats.FillDuration = track.lap(PhaseFill)
+			return nil, err
+		}
+		stats.SelectedCount = countSelected(res)
+	} else if stats.Negatives >= minSize {
+		// If there is enough negative costs return only those
+		stats.Path = PathNegatives
+	} else {
+		stats.LeftToFill = minSize - stats.Negatives
 
-			if smallest.Len() < leftToFill {
-				heap.Push(smallest, c)
-				continue
+		switch {
+		case ascending:
+			// Negatives form the prefix and the smallest remaining costs directly follow it.
+			stats.Path = PathSortedAscending
+			for i := stats.Negatives; i < minSize; i++ {
+				res[i] = 1
 			}
-
-			highest := (*smallest)[0]
-			if value < highest.price || (value == highest.price && index < highest.index) {
-				(*smallest)[0] = c
-				heap.Fix(smallest, 0)
-				replacements++
+		case descending:
+			stats.Path = PathSortedDescending
+			selectSortedDescending(work, res, stats.Negatives, stats.LeftToFill)
+		default:
+			solver := resolveSolver(cfg.solver, len(prices), stats.LeftToFill)
+			stats.Solver = solver.Name()
+			stats.Path = Path(stats.Solver)
+			if p, ok := solver.(progressSolver); ok && track.observed() {
+				// The callback captures a copy so that track itself stays on the stack.
+				clock := track.phaseClock
+				stats.Replacements = p.selectWithProgress(work, res, stats.LeftToFill, func(done int) {
+					clock.progress(PhaseFill, done, len(prices))
+				})
+			} else {
+				stats.Replacements = solver.Select(work, res, stats.LeftToFill)
 			}
 		}
-
+		stats.SelectedCount = minSize
+	}
+	if cfg.itemConstrained() || cfg.constraintReport != nil {
+		report, err := applyItemConstraints(work, res, cfg, &stats)
+		// A search that gave up still reports the binding constraints, with Exact false.
+		if cfg.constraintReport != nil && (err == nil || errors.Is(err, ErrSearchLimit)) {
+			*cfg.constraintReport = report
+		}
+		if err != nil {
+			stats.FillDuration = track.lap(PhaseFill)
+			return nil, err
+		}
 	}
+	stats.FillDuration = track.lap(PhaseFill)
 
-	// update res with the missing element
-	for _, v := range *smallest {
-		res[v.index] = 1
-		selectedCount++
+	// The plain run and the totals are only worth computing when someone is listening.
+	_, silent := cfg.observer.(NoOpObserver)
+	if cfg.previous != nil {
+		stats.Switches = countSwitches(cfg.previous, res)
 	}
+	if cfg.previous != nil && !silent {
+		// The plain run keeps the spacing constraints, so only the switching penalty differs.
+		plainOpts := []Option{WithSolver(cfg.solver), WithContext(cfg.ctx)}
+		if cfg.runCoverage != 0 {
+			plainOpts = append(plainOpts, WithRunCoverage(cfg.runCoverage))
+		}
+		if cfg.noAdjacent {
+			plainOpts = append(plainOpts, WithNoAdjacent())
+		}
+		plainOpts = append(plainOpts, WithConflicts(cfg.conflicts...), WithImplications(cfg.implications...))
+		if plain, err := CostOptimization(prices, plainOpts...); err == nil {
+			stats.SwitchesAvoided = countSwitches(cfg.previous, plain) - stats.Switches
+		}
+	}
+	if !silent {
+		stats.CutoffCost, stats.TotalCost = selectionTotals(prices, res)
+	}
+	stats.OutputDuration = track.lap(PhaseOutput)
 
 	return res, nil
 }
 
+// selectionTotals returns the largest non-negative cost selected (0 when none was) and the total
+// cost of the selection. The cutoff is read from the selection itself, since constrained paths
+// can pick non-negative costs without LeftToFill being set.
+func selectionTotals(prices []float64, res []int) (float64, float64) {
+	cutoff := 0.0
+	for i, value := range prices {
+		if res[i] == 1 && value > cutoff {
+			cutoff = value
+		}
+	}
+	total, _ := TotalCost(prices, res)
+	return cutoff, total
+}
+
+// selectSortedDescending marks the leftToFill smallest non-negative costs of a non-increasing input.
+// They sit right before the negative suffix; when the cutoff value repeats, the lowest indices of
+// that run are taken instead, to keep the usual tie-break.
+func selectSortedDescending(prices []float64, res []int, negatives, leftToFill int) {
+	nonNegatives := len(prices) - negatives
+	first := nonNegatives - leftToFill
+
+	runStart, runEnd := first, first
+	for runStart > 0 && prices[runStart-1] == prices[first] {
+		runStart--
+	}
+	for runEnd < nonNegatives && prices[runEnd] == prices[first] {
+		runEnd++
+	}
+
+	for i := runEnd; i < nonNegatives; i++ {
+		res[i] = 1
+	}
+	for i := runStart; i < runStart+leftToFill-(nonNegatives-runEnd); i++ {
+		res[i] = 1
+	}
+}
+
+// requiredCount returns the minimum number of elements that must be selected out of n, i.e. ⌈n/2⌉.
+func requiredCount(n int) int {
+	return n/2 + n%2
+}
+
 // TotalCost calculates the total cost by multiplying each price with its corresponding optimization flag and summing the results.
 func TotalCost(prices []float64, optimization []int) (float64, error) {
 	result := 0.0
@@ -1,6 +1,7 @@
 package optimization
 
 import (
+	"fmt"
 	"math/rand/v2"
 	"testing"
 )
@@ -139,3 +140,45 @@ func randFloats(min, max float64, n int) []float64 {
 	}
 	return res
 }
+
+func BenchmarkInt64Radix(b *testing.B) {
+	for _, n := range []int{10000, 1000000} {
+		cents := randInt64s(-10000, 50000, n)
+		floats := make([]float64, n)
+		for i, c := range cents {
+			floats[i] = float64(c)
+		}
+
+		b.Run(fmt.Sprintf("Radix_%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			for b.Loop() {
+				benchOutput, benchError = CostOptimizationInt64(cents)
+			}
+		})
+
+		b.Run(fmt.Sprintf("Float_%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			for b.Loop() {
+				benchOutput, benchError = CostOptimization(floats)
+			}
+		})
+	}
+}
+
+func BenchmarkSolvers(b *testing.B) {
+	solvers := []Solver{HeapSolver{}, SortSolver{}, QuickselectSolver{}, ParallelSolver{}}
+	for _, n := range []int{100, 10000, 1000000} {
+		costs := randFloats(-100.0, 500.0, n)
+		for _, solver := range solvers {
+			b.Run(fmt.Sprintf("%s_%d", solver.Name(), n), func(b *testing.B) {
+				b.ReportAllocs()
+				b.ResetTimer()
+				for b.Loop() {
+					benchOutput, benchError = CostOptimization(costs, WithSolver(solver))
+				}
+			})
+		}
+	}
+}
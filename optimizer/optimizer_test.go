@@ -1,7 +1,11 @@
 package optimization
 
 import (
+	"errors"
+	"io"
 	"math"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
@@ -505,8 +509,155 @@ func TestTotalCostWithNegativeInfinity(t *testing.T) {
 	}
 }
 
+func TestSortedInputs(t *testing.T) {
+	cases := []struct {
+		costs []float64
+		path  Path
+	}{
+		{[]float64{-3, -1, 0, 2, 2, 2, 9}, PathSortedAscending},
+		{[]float64{1, 1, 1, 1}, PathSortedAscending},
+		{[]float64{9, 5, 5, 5, 5, 2, -1}, PathSortedDescending},
+		{[]float64{7, 7, 7, 3, 3, -2, -8, -9}, PathSortedDescending},
+		{[]float64{math.Inf(1), 4, 4, 0, math.Inf(-1)}, PathSortedDescending},
+		{[]float64{-1, -2, -3, 4}, PathNegatives},
+		{[]float64{3, 1, 2, 5}, PathHeap},
+	}
+
+	for _, c := range cases {
+		var obs statsRecorder
+		result, err := CostOptimization(c.costs, WithObserver(&obs))
+		if err != nil {
+			t.Fatalf("CostOptimization returned unexpected error: %v", err)
+		}
+		if obs.last.Path != c.path {
+			t.Fatalf("CostOptimization(%v) took path %q, expected %q", c.costs, obs.last.Path, c.path)
+		}
+		expected := referenceSelection(c.costs)
+		for i := range expected {
+			if result[i] != expected[i] {
+				t.Fatalf("CostOptimization(%v) got %v, expected %v", c.costs, result, expected)
+			}
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	var obs statsRecorder
+
+	costs := []float64{-10, 17, 15, -40, 20, 3}
+	if _, err := CostOptimization(costs, WithObserver(&obs)); err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	s := obs.last
+	if s.Negatives != 2 || s.LeftToFill != 1 || s.SelectedCount != 3 {
+		t.Fatalf("got negatives=%d leftToFill=%d selected=%d, expected 2, 1, 3", s.Negatives, s.LeftToFill, s.SelectedCount)
+	}
+	if s.CutoffCost != 3 || s.TotalCost != -47 {
+		t.Fatalf("got cutoff=%v total=%v, expected 3 and -47", s.CutoffCost, s.TotalCost)
+	}
+	if s.Err != nil || s.Path != PathHeap {
+		t.Fatalf("got err=%v path=%q, expected nil and %q", s.Err, s.Path, PathHeap)
+	}
+	if phases := s.ValidateDuration + s.NegativeScanDuration + s.FillDuration + s.OutputDuration; phases > s.Duration {
+		t.Fatalf("phase durations %v exceed the total duration %v", phases, s.Duration)
+	}
+
+	if _, err := CostOptimization([]float64{1, math.NaN()}, WithObserver(&obs)); obs.last.Err != err || err != ErrInvalidNumber {
+		t.Fatalf("Stats.Err is %v, expected %v", obs.last.Err, ErrInvalidNumber)
+	}
+	if _, err := CostOptimization(nil, WithObserver(&obs)); obs.last.Err != err || err != ErrEmptyInput {
+		t.Fatalf("Stats.Err is %v, expected %v", obs.last.Err, ErrEmptyInput)
+	}
+}
+
+func TestUnsupportedOptions(t *testing.T) {
+	costs := []float64{3, 1, 2, 5}
+	var report ConstraintReport
+	var audit AuditRecord
+	run := map[string]func(...Option) error{
+		"CostOptimization": func(opts ...Option) error {
+			_, err := CostOptimization(costs, opts...)
+			return err
+		},
+		"Window": func(opts ...Option) error {
+			_, err := CostOptimizationWindow(costs, opts...)
+			return err
+		},
+		"Stream": func(opts ...Option) error {
+			return CostOptimizationStream(strings.NewReader("3 1 2 5"), io.Discard, FormatText, opts...)
+		},
+		"Seq": func(opts ...Option) error {
+			_, err := CostOptimizationSeq(slices.Values(costs), opts...)
+			return err
+		},
+		"Int64": func(opts ...Option) error {
+			_, err := CostOptimizationInt64([]int64{3, 1, 2, 5}, opts...)
+			return err
+		},
+		"Approx": func(opts ...Option) error {
+			_, err := CostOptimizationApprox(costs, opts...)
+			return err
+		},
+		"Plan": func(opts ...Option) error {
+			_, err := PlanPeriods([][]float64{costs}, make([]Transition, len(costs)), opts...)
+			return err
+		},
+	}
+	tests := []struct {
+		entry  string
+		option Option
+		named  string
+	}{
+		{"Approx", WithRecorder(NewRecorder(io.Discard, RecorderConfig{})), "WithRecorder"},
+		{"Stream", WithRecorder(NewRecorder(io.Discard, RecorderConfig{})), "WithRecorder"},
+		{"Plan", WithAudit(&audit, auditKey), "WithAudit"},
+		{"Stream", WithPreviousSelection([]int{1, 0, 1, 0}, 1), "WithPreviousSelection"},
+		{"CostOptimization", WithCircularWindow(), "WithCircularWindow"},
+		{"Seq", WithRunCoverage(2), "WithRunCoverage"},
+		{"Window", WithNoAdjacent(), "WithNoAdjacent"},
+		{"Int64", WithConstraintReport(&report), "WithConstraintReport"},
+		{"Plan", WithConflicts(Conflict{0, 1}), "WithConflicts"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entry+"/"+tt.named, func(t *testing.T) {
+			if err := run[tt.entry](tt.option); !errors.Is(err, ErrUnsupportedOption) || !strings.Contains(err.Error(), tt.named) {
+				t.Fatalf("got %v, expected %v naming %s", err, ErrUnsupportedOption, tt.named)
+			}
+		})
+	}
+}
+
 // Testing helpers
 
+type statsRecorder struct {
+	last  Stats
+	calls int
+}
+
+func (r *statsRecorder) Observe(s Stats) {
+	r.last = s
+	r.calls++
+}
+
+// referenceSelection selects the negatives and then the smallest remaining costs by sorting, lower indices first.
+func referenceSelection(costs []float64) []int {
+	res := make([]int, len(costs))
+	order := make([]int, len(costs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return costs[order[a]] < costs[order[b]] })
+
+	need := len(costs)/2 + len(costs)%2
+	for rank, i := range order {
+		if costs[i] < 0 || rank < need {
+			res[i] = 1
+		}
+	}
+	return res
+}
+
 func countOnes(optimized []int) (res int) {
 	for _, value := range optimized {
 		if value == 1 {
@@ -1,7 +1,38 @@
 package optimization
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// ErrUnsupportedOption is returned when an entry point is given an option that changes what a call
+// returns or records but that it cannot honour, rather than silently ignoring the option.
+var ErrUnsupportedOption = errors.New("option is not supported by this function")
+
 type options struct {
 	observer Observer
+	ctx      context.Context
+	solver   Solver
+	tempDir  string
+	recorder *Recorder
+	audit    *AuditRecord
+	auditKey []byte
+
+	previous []int
+	penalty  float64
+	circular bool
+
+	runCoverage int
+	noAdjacent  bool
+
+	conflicts        []Conflict
+	implications     []Implication
+	constraintReport *ConstraintReport
+
+	sampleSize int
+	seed       uint64
 }
 
 type Option func(*options)
@@ -14,12 +45,128 @@ func WithObserver(o Observer) Option {
 	}
 }
 
+// WithSolver sets the algorithm CostOptimization uses to pick the smallest remaining costs.
+// The default is HeapSolver; AutoSolver chooses one per call.
+func WithSolver(s Solver) Option {
+	return func(opt *options) {
+		if s != nil {
+			opt.solver = s
+		}
+	}
+}
+
+// WithContext passes the caller's context. When it carries a Tracer (see ContextWithTracer), each
+// call opens a child span with the call's phases as events and its Stats as attributes.
+func WithContext(ctx context.Context) Option {
+	return func(opt *options) {
+		opt.ctx = ctx
+	}
+}
+
+// WithRecorder captures the inputs, options and output of each CostOptimization call into r,
+// subject to its sampling and size limits, so surprising results can be replayed later.
+func WithRecorder(r *Recorder) Option {
+	return func(opt *options) {
+		opt.recorder = r
+	}
+}
+
+// WithAudit fills dst with the AuditRecord of each successful CostOptimization call, sealed with
+// key so that VerifyAudit given the same key detects any edit. The key must not be empty.
+func WithAudit(dst *AuditRecord, key []byte) Option {
+	return func(opt *options) {
+		opt.audit = dst
+		opt.auditKey = key
+	}
+}
+
+// WithTempDir sets the directory used by CostOptimizationStream to spill costs that cannot be re-read.
+// The default is os.TempDir.
+func WithTempDir(dir string) Option {
+	return func(opt *options) {
+		opt.tempDir = dir
+	}
+}
+
+// WithSampleSize sets how many costs CostOptimizationApprox samples to estimate the cutoff.
+// Larger samples tighten the estimate; the default is 4096.
+func WithSampleSize(n int) Option {
+	return func(opt *options) {
+		opt.sampleSize = n
+	}
+}
+
+// WithSeed sets the seed of the sampler used by CostOptimizationApprox, so runs can be reproduced.
+func WithSeed(seed uint64) Option {
+	return func(opt *options) {
+		opt.seed = seed
+	}
+}
+
+// feature is a set of the options that change what a call returns or records. Options that only
+// observe or tune a call are accepted by every entry point and take effect where they apply:
+// WithSolver in CostOptimization, WithTempDir in CostOptimizationStream, WithSampleSize and WithSeed
+// in CostOptimizationApprox, and WithObserver and WithContext everywhere.
+type feature uint
+
+const (
+	featureRecorder feature = 1 << iota
+	featureAudit
+	featurePrevious
+	featureCircular
+	featureRunCoverage
+	featureNoAdjacent
+	featureConflicts
+	featureImplications
+	featureConstraintReport
+)
+
+// featureNames holds the option behind each feature bit, in bit order.
+var featureNames = [...]string{
+	"WithRecorder", "WithAudit", "WithPreviousSelection", "WithCircularWindow", "WithRunCoverage",
+	"WithNoAdjacent", "WithConflicts", "WithImplications", "WithConstraintReport",
+}
+
+func (o options) features() feature {
+	var f feature
+	set := func(bit feature, on bool) {
+		if on {
+			f |= bit
+		}
+	}
+	set(featureRecorder, o.recorder != nil)
+	set(featureAudit, o.audit != nil)
+	set(featurePrevious, o.previous != nil)
+	set(featureCircular, o.circular)
+	set(featureRunCoverage, o.runCoverage != 0)
+	set(featureNoAdjacent, o.noAdjacent)
+	set(featureConflicts, len(o.conflicts) > 0)
+	set(featureImplications, len(o.implications) > 0)
+	set(featureConstraintReport, o.constraintReport != nil)
+	return f
+}
+
+// supports returns an ErrUnsupportedOption error naming the first option set beyond supported.
+func (o options) supports(supported feature) error {
+	extra := o.features() &^ supported
+	if extra == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedOption, featureNames[bits.TrailingZeros(uint(extra))])
+}
+
 func applyOptions(opts []Option) options {
-	cfg := options{observer: NoOpObserver{}}
+	cfg := options{observer: NoOpObserver{}, solver: HeapSolver{}}
+	if len(opts) == 0 {
+		// Options are applied through a pointer that escapes, so calls without any skip it.
+		return cfg
+	}
+	applied := new(options)
+	*applied = cfg
 	for _, o := range opts {
 		if o != nil {
-			o(&cfg)
+			o(applied)
 		}
 	}
-	return cfg
+	return *applied
 }
\ No newline at end of file
@@ -0,0 +1,466 @@
+package optimization
+
+import (
+	"errors"
+	"math"
+)
+
+var ErrInfiniteCost = errors.New("costs must be finite")
+
+const (
+	PathPlanDP   Path = "plan_dp"   // per-item programs already covered every period
+	PathPlanFlow Path = "plan_flow" // coverage coupling solved as a min-cost flow
+)
+
+// Transition is the cost of turning one item on or off between two consecutive periods.
+type Transition struct {
+	On  float64
+	Off float64
+}
+
+// Plan is the output of PlanPeriods.
+type Plan struct {
+	Schedule       [][]int   // Schedule[t][i] is 1 when item i is selected in period t
+	PeriodTotals   []float64 // TotalCost of each period's selection
+	TransitionCost float64   // on/off costs paid between periods
+	Total          float64   // sum of PeriodTotals and TransitionCost
+	Switches       int       // number of on/off transitions
+}
+
+// PlanPeriods selects items over consecutive periods. costs[t][i] is the cost of item i in period t
+// and transitions[i] what it costs to turn item i on or off between two periods; the first period
+// is free of transition costs. Every period selects at least ⌈n/2⌉ of its n items, as
+// CostOptimization does, and the sum of selected costs and transition costs is minimal.
+//
+// Each item alone is a two-state dynamic program over the periods. When those independent
+// programs already cover every period, their schedules are returned (Path plan_dp). Otherwise the
+// items are coupled by the coverage constraints and the problem is solved exactly as a min-cost
+// flow (Path plan_flow): every item is a chain of unit-capacity arcs through time, items that are
+// off share a pool arc of capacity n-⌈n/2⌉ per period, and turning on or off moves flow between an
+// item chain and the pool. That takes O(⌈n/2⌉ · E log E) time with E ≈ 3 · periods · items.
+//
+// Transition costs must be finite and non-negative (ErrInvalidPenalty) and all costs finite
+// (ErrInfiniteCost).
+func PlanPeriods(costs [][]float64, transitions []Transition, opts ...Option) (_ Plan, err error) {
+	n := 0
+	if len(costs) > 0 {
+		n = len(costs[0])
+	}
+	cfg := applyOptions(opts)
+	track := cfg.beginCall("optimization.PlanPeriods", len(costs)*n)
+	stats := Stats{N: len(costs) * n}
+	defer track.finish(&stats, &err)
+
+	if err = cfg.supports(0); err != nil {
+		return Plan{}, err
+	}
+
+	if len(costs) == 0 || n == 0 {
+		return Plan{}, ErrEmptyInput
+	}
+	err = validatePlan(costs, transitions, n)
+	stats.ValidateDuration = track.lap(PhaseValidate)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	schedule := planItems(costs, transitions)
+	stats.Path = PathPlanDP
+	if !covered(schedule) {
+		schedule = planFlow(costs, transitions)
+		stats.Path = PathPlanFlow
+	}
+	stats.FillDuration = track.lap(PhaseFill)
+
+	plan := newPlan(costs, transitions, schedule)
+	for _, row := range schedule {
+		stats.SelectedCount += countSelected(row)
+	}
+	stats.TotalCost = plan.Total
+	stats.Switches = plan.Switches
+	stats.OutputDuration = track.lap(PhaseOutput)
+	return plan, nil
+}
+
+// validatePlan checks that every period has n finite costs and every transition a valid penalty.
+func validatePlan(costs [][]float64, transitions []Transition, n int) error {
+	if len(transitions) != n {
+		return ErrDifferentSizes
+	}
+	for _, row := range costs {
+		if len(row) != n {
+			return ErrDifferentSizes
+		}
+		for _, value := range row {
+			if math.IsNaN(value) {
+				return ErrInvalidNumber
+			}
+			if math.IsInf(value, 0) {
+				return ErrInfiniteCost
+			}
+		}
+	}
+	for _, tr := range transitions {
+		if !validPenalty(tr.On) || !validPenalty(tr.Off) {
+			return ErrInvalidPenalty
+		}
+	}
+	return nil
+}
+
+func validPenalty(p float64) bool {
+	return !math.IsNaN(p) && !math.IsInf(p, 0) && p >= 0
+}
+
+// planItems solves every item on its own, ignoring the coverage constraints.
+func planItems(costs [][]float64, transitions []Transition) [][]int {
+	periods, n := len(costs), len(costs[0])
+	schedule := newSchedule(periods, n)
+	// from[t][s] is the state at t-1 on the best path that is in state s at t.
+	from := make([][2]int8, periods)
+
+	for i := range n {
+		tr := transitions[i]
+		off, on := 0.0, costs[0][i]
+		for t := 1; t < periods; t++ {
+			stayOff, switchOff := off, on+tr.Off
+			stayOn, switchOn := on, off+tr.On
+
+			from[t] = [2]int8{0, 1}
+			if switchOff < stayOff {
+				off, from[t][0] = switchOff, 1
+			} else {
+				off = stayOff
+			}
+			if switchOn < stayOn {
+				on, from[t][1] = switchOn+costs[t][i], 0
+			} else {
+				on = stayOn + costs[t][i]
+			}
+		}
+
+		state := int8(0)
+		if on < off {
+			state = 1
+		}
+		for t := periods - 1; t >= 0; t-- {
+			schedule[t][i] = int(state)
+			if t > 0 {
+				state = from[t][state]
+			}
+		}
+	}
+	return schedule
+}
+
+// covered reports whether every period selects at least ⌈n/2⌉ items.
+func covered(schedule [][]int) bool {
+	for _, row := range schedule {
+		if countSelected(row) < requiredCount(len(row)) {
+			return false
+		}
+	}
+	return true
+}
+
+// planFlow solves the coupled problem as a min-cost flow of n units. Nodes are laid out per
+// period boundary t: item i is node t*(n+1)+i and the off pool is node t*(n+1)+n.
+func planFlow(costs [][]float64, transitions []Transition) [][]int {
+	periods, n := len(costs), len(costs[0])
+	node := func(t, i int) int { return t*(n+1) + i }
+	source := node(periods+1, 0)
+	sink := source + 1
+
+	g := newFlowGraph(sink + 1)
+	offCap := n - requiredCount(n)
+
+	g.addArc(source, node(0, n), n, 0)
+	onArcs := make([][]int, periods)
+	for t := range periods {
+		pool := node(t, n)
+		onArcs[t] = make([]int, n)
+		for i := range n {
+			if t == 0 {
+				g.addArc(pool, node(t, i), 1, 0)
+			} else {
+				g.addArc(pool, node(t, i), 1, transitions[i].On)
+				g.addArc(node(t, i), pool, 1, transitions[i].Off)
+			}
+			onArcs[t][i] = g.addArc(node(t, i), node(t+1, i), 1, costs[t][i])
+		}
+		g.addArc(pool, node(t+1, n), offCap, 0)
+	}
+	for i := range n + 1 {
+		g.addArc(node(periods, i), sink, n, 0)
+	}
+
+	// Initial potentials: shortest distances in the original graph, computed boundary by boundary.
+	// Within a boundary the only arcs go through the pool and on+off >= 0, so one pass suffices.
+	dist := g.potential
+	for v := range dist {
+		dist[v] = math.Inf(1)
+	}
+	dist[source] = 0
+	for t := 0; t <= periods; t++ {
+		pool := node(t, n)
+		if t == 0 {
+			dist[pool] = 0
+		} else {
+			dist[pool] = dist[node(t-1, n)]
+			for i := range n {
+				dist[node(t, i)] = dist[node(t-1, i)] + costs[t-1][i]
+			}
+		}
+		if t == 0 || t == periods {
+			for i := range n {
+				dist[node(t, i)] = min(dist[node(t, i)], dist[pool])
+			}
+			continue
+		}
+		for i := range n {
+			dist[pool] = min(dist[pool], dist[node(t, i)]+transitions[i].Off)
+		}
+		for i := range n {
+			dist[node(t, i)] = min(dist[node(t, i)], dist[pool]+transitions[i].On)
+		}
+	}
+	dist[sink] = math.Inf(1)
+	for i := range n + 1 {
+		dist[sink] = min(dist[sink], dist[node(periods, i)])
+	}
+
+	g.minCostFlow(source, sink, n)
+
+	schedule := newSchedule(periods, n)
+	for t, arcs := range onArcs {
+		for i, a := range arcs {
+			if g.arcs[a].cap == 0 {
+				schedule[t][i] = 1
+			}
+		}
+	}
+	return schedule
+}
+
+// newPlan prices a schedule.
+func newPlan(costs [][]float64, transitions []Transition, schedule [][]int) Plan {
+	plan := Plan{Schedule: schedule, PeriodTotals: make([]float64, len(costs))}
+	for t, row := range schedule {
+		plan.PeriodTotals[t], _ = TotalCost(costs[t], row)
+		plan.Total += plan.PeriodTotals[t]
+		if t == 0 {
+			continue
+		}
+		for i, v := range row {
+			switch prev := schedule[t-1][i]; {
+			case v > prev:
+				plan.TransitionCost += transitions[i].On
+				plan.Switches++
+			case v < prev:
+				plan.TransitionCost += transitions[i].Off
+				plan.Switches++
+			}
+		}
+	}
+	plan.Total += plan.TransitionCost
+	return plan
+}
+
+func newSchedule(periods, n int) [][]int {
+	flags := make([]int, periods*n)
+	schedule := make([][]int, periods)
+	for t := range schedule {
+		schedule[t] = flags[t*n : (t+1)*n : (t+1)*n]
+	}
+	return schedule
+}
+
+func countSelected(sel []int) int {
+	count := 0
+	for _, v := range sel {
+		count += v
+	}
+	return count
+}
+
+// flowArc is an arc of the residual graph; arc a^1 is the reverse of arc a.
+type flowArc struct {
+	to   int
+	cap  int
+	cost float64
+}
+
+type flowGraph struct {
+	arcs      []flowArc
+	out       [][]int
+	potential []float64
+}
+
+func newFlowGraph(nodes int) *flowGraph {
+	return &flowGraph{out: make([][]int, nodes), potential: make([]float64, nodes)}
+}
+
+// addArc adds an arc and its zero-capacity reverse, and returns the index of the arc.
+func (g *flowGraph) addArc(from, to, capacity int, cost float64) int {
+	a := len(g.arcs)
+	g.arcs = append(g.arcs, flowArc{to, capacity, cost}, flowArc{from, 0, -cost})
+	g.out[from] = append(g.out[from], a)
+	g.out[to] = append(g.out[to], a+1)
+	return a
+}
+
+// minCostFlow sends amount units from source to sink by the primal-dual method: Dijkstra on
+// reduced costs updates the potentials, then a blocking flow saturates every shortest path at
+// once. g.potential must hold valid potentials (no negative reduced cost).
+func (g *flowGraph) minCostFlow(source, sink, amount int) {
+	dist := make([]float64, len(g.out))
+	level := make([]int, len(g.out))
+	next := make([]int, len(g.out))
+	var queue flowQueue
+
+	for amount > 0 {
+		for v := range dist {
+			dist[v] = math.Inf(1)
+		}
+		dist[source] = 0
+		queue.push(flowItem{source, 0})
+		for len(queue) > 0 {
+			item := queue.pop()
+			if item.dist > dist[item.node] {
+				continue
+			}
+			if item.node == sink {
+				break
+			}
+			for _, a := range g.out[item.node] {
+				arc := g.arcs[a]
+				if arc.cap == 0 {
+					continue
+				}
+				if d := item.dist + g.reduced(item.node, a); d < dist[arc.to] {
+					dist[arc.to] = d
+					queue.push(flowItem{arc.to, d})
+				}
+			}
+		}
+		if math.IsInf(dist[sink], 1) {
+			return
+		}
+		// Nodes not settled before the sink are at least as far; capping keeps potentials valid.
+		for v, d := range dist {
+			g.potential[v] += min(d, dist[sink])
+		}
+		queue = queue[:0]
+
+		for amount > 0 && g.levels(source, sink, level) {
+			clear(next)
+			for amount > 0 {
+				pushed := g.augment(source, sink, amount, level, next)
+				if pushed == 0 {
+					break
+				}
+				amount -= pushed
+			}
+		}
+	}
+}
+
+// reduced returns the reduced cost of arc a leaving u. Rounding can leave it slightly negative.
+func (g *flowGraph) reduced(u, a int) float64 {
+	arc := g.arcs[a]
+	return max(arc.cost+g.potential[u]-g.potential[arc.to], 0)
+}
+
+// admissible reports whether arc a leaving u has residual capacity and lies on a shortest path.
+func (g *flowGraph) admissible(u, a int) bool {
+	arc := g.arcs[a]
+	scale := max(1, math.Abs(arc.cost), math.Abs(g.potential[u]))
+	return arc.cap > 0 && g.reduced(u, a) <= 1e-9*scale
+}
+
+// levels numbers the nodes by breadth-first distance from source over admissible arcs, and
+// reports whether sink is reachable.
+func (g *flowGraph) levels(source, sink int, level []int) bool {
+	for v := range level {
+		level[v] = -1
+	}
+	level[source] = 0
+	queue := []int{source}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		for _, a := range g.out[u] {
+			if v := g.arcs[a].to; level[v] < 0 && g.admissible(u, a) {
+				level[v] = level[u] + 1
+				queue = append(queue, v)
+			}
+		}
+	}
+	return level[sink] >= 0
+}
+
+// augment pushes at most limit units along one admissible path with increasing levels, skipping
+// arcs already found to be dead through next.
+func (g *flowGraph) augment(u, sink, limit int, level, next []int) int {
+	if u == sink {
+		return limit
+	}
+	for ; next[u] < len(g.out[u]); next[u]++ {
+		a := g.out[u][next[u]]
+		v := g.arcs[a].to
+		if level[v] != level[u]+1 || !g.admissible(u, a) {
+			continue
+		}
+		if pushed := g.augment(v, sink, min(limit, g.arcs[a].cap), level, next); pushed > 0 {
+			g.arcs[a].cap -= pushed
+			g.arcs[a^1].cap += pushed
+			return pushed
+		}
+	}
+	return 0
+}
+
+type flowItem struct {
+	node int
+	dist float64
+}
+
+// flowQueue is a binary min-heap on dist, typed to avoid the boxing of container/heap.
+type flowQueue []flowItem
+
+func (q *flowQueue) push(item flowItem) {
+	*q = append(*q, item)
+	h := *q
+	for i := len(h) - 1; i > 0; {
+		parent := (i - 1) / 2
+		if h[parent].dist <= h[i].dist {
+			break
+		}
+		h[parent], h[i] = h[i], h[parent]
+		i = parent
+	}
+}
+
+func (q *flowQueue) pop() flowItem {
+	h := *q
+	top := h[0]
+	last := len(h) - 1
+	h[0] = h[last]
+	h = h[:last]
+	for i := 0; ; {
+		smallest := i
+		if l := 2*i + 1; l < len(h) && h[l].dist < h[smallest].dist {
+			smallest = l
+		}
+		if r := 2*i + 2; r < len(h) && h[r].dist < h[smallest].dist {
+			smallest = r
+		}
+		if smallest == i {
+			break
+		}
+		h[i], h[smallest] = h[smallest], h[i]
+		i = smallest
+	}
+	*q = h
+	return top
+}
@@ -0,0 +1,146 @@
+package optimization
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestPlanPeriods(t *testing.T) {
+	costs := [][]float64{
+		{1, 5, 2, 8},
+		{6, 1, 2, 8},
+		{1, 5, 2, 8},
+	}
+	transitions := []Transition{{3, 3}, {3, 3}, {3, 3}, {3, 3}}
+	stats := &statsRecorder{}
+
+	plan, err := PlanPeriods(costs, transitions, WithObserver(stats))
+	if err != nil {
+		t.Fatalf("PlanPeriods returned unexpected error: %v", err)
+	}
+	// Switching item 0 out for item 1 in the middle period would save 5 but cost 12.
+	for period, row := range plan.Schedule {
+		if !slices.Equal(row, []int{1, 0, 1, 0}) {
+			t.Fatalf("period %d: selection %v, expected [1 0 1 0]", period, row)
+		}
+	}
+	if !slices.Equal(plan.PeriodTotals, []float64{3, 8, 3}) || plan.TransitionCost != 0 || plan.Total != 14 {
+		t.Fatalf("totals %v + %v = %v, expected [3 8 3] + 0 = 14", plan.PeriodTotals, plan.TransitionCost, plan.Total)
+	}
+	if stats.last.Path != PathPlanFlow || stats.last.N != 12 || stats.last.SelectedCount != 6 || stats.last.TotalCost != 14 {
+		t.Fatalf("unexpected stats %+v", stats.last)
+	}
+
+	// With cheap transitions the middle period switches.
+	cheap := []Transition{{1, 1}, {1, 1}, {1, 1}, {1, 1}}
+	plan, _ = PlanPeriods(costs, cheap)
+	if !slices.Equal(plan.Schedule[1], []int{0, 1, 1, 0}) || plan.Switches != 4 || plan.Total != 13 {
+		t.Fatalf("schedule %v with %d switches and total %v", plan.Schedule, plan.Switches, plan.Total)
+	}
+}
+
+func TestPlanPeriodsUncoupled(t *testing.T) {
+	costs := [][]float64{{-1, -2, 3}, {-1, 4, -3}}
+	stats := &statsRecorder{}
+	plan, err := PlanPeriods(costs, []Transition{{0, 0}, {0, 0}, {0, 0}}, WithObserver(stats))
+	if err != nil {
+		t.Fatalf("PlanPeriods returned unexpected error: %v", err)
+	}
+	for period, row := range plan.Schedule {
+		if expected, _ := CostOptimization(costs[period]); !slices.Equal(row, expected) {
+			t.Fatalf("period %d: selection %v, expected %v", period, row, expected)
+		}
+	}
+	if stats.last.Path != PathPlanDP {
+		t.Fatalf("path %s, expected %s", stats.last.Path, PathPlanDP)
+	}
+}
+
+func TestPlanPeriodsIsOptimal(t *testing.T) {
+	r := rand.New(rand.NewPCG(4, 7))
+	for range 300 {
+		periods, n := 1+r.IntN(3), 1+r.IntN(4)
+		costs := make([][]float64, periods)
+		for p := range costs {
+			costs[p] = make([]float64, n)
+			for i := range costs[p] {
+				costs[p][i] = float64(r.IntN(13) - 4)
+			}
+		}
+		transitions := make([]Transition, n)
+		for i := range transitions {
+			transitions[i] = Transition{float64(r.IntN(5)), float64(r.IntN(5))}
+		}
+
+		plan, err := PlanPeriods(costs, transitions)
+		if err != nil {
+			t.Fatalf("PlanPeriods returned unexpected error: %v", err)
+		}
+		if !covered(plan.Schedule) {
+			t.Fatalf("costs %v: schedule %v misses the coverage", costs, plan.Schedule)
+		}
+		if best := bruteForcePlan(costs, transitions); plan.Total != best {
+			t.Fatalf("costs %v transitions %v: total %v, optimum %v", costs, transitions, plan.Total, best)
+		}
+	}
+}
+
+func TestPlanPeriodsErrors(t *testing.T) {
+	valid := []Transition{{1, 1}, {1, 1}}
+	cases := []struct {
+		costs       [][]float64
+		transitions []Transition
+		err         error
+	}{
+		{nil, valid, ErrEmptyInput},
+		{[][]float64{{}}, nil, ErrEmptyInput},
+		{[][]float64{{1, 2}, {1}}, valid, ErrDifferentSizes},
+		{[][]float64{{1, 2}}, valid[:1], ErrDifferentSizes},
+		{[][]float64{{1, math.NaN()}}, valid, ErrInvalidNumber},
+		{[][]float64{{1, math.Inf(1)}}, valid, ErrInfiniteCost},
+		{[][]float64{{1, 2}}, []Transition{{1, 1}, {-1, 1}}, ErrInvalidPenalty},
+	}
+	for _, c := range cases {
+		if _, err := PlanPeriods(c.costs, c.transitions); !errors.Is(err, c.err) {
+			t.Errorf("costs %v: expected %v, got %v", c.costs, c.err, err)
+		}
+	}
+}
+
+func BenchmarkPlanPeriods(b *testing.B) {
+	const periods, n = 24, 200
+	costs := make([][]float64, periods)
+	for p := range costs {
+		costs[p] = randFloats(0, 100, n)
+	}
+	transitions := make([]Transition, n)
+	for i := range transitions {
+		transitions[i] = Transition{20, 20}
+	}
+
+	b.ResetTimer()
+	for b.Loop() {
+		PlanPeriods(costs, transitions)
+	}
+}
+
+// bruteForcePlan returns the smallest total over every schedule that covers each period.
+func bruteForcePlan(costs [][]float64, transitions []Transition) float64 {
+	periods, n := len(costs), len(costs[0])
+	best := math.Inf(1)
+	schedule := newSchedule(periods, n)
+	for mask := range 1 << (periods * n) {
+		for p := range periods {
+			for i := range n {
+				schedule[p][i] = mask >> (p*n + i) & 1
+			}
+		}
+		if covered(schedule) {
+			best = min(best, newPlan(costs, transitions, schedule).Total)
+		}
+	}
+	return best
+}
@@ -0,0 +1,169 @@
+// Package promobserver provides an optimization.Observer that aggregates Stats into counters and
+// histograms and serves them in the Prometheus text exposition format, without depending on the
+// Prometheus client library.
+package promobserver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	optimization "github.com/greyskp/cost_optimization/optimizer"
+)
+
+// DefaultNamespace prefixes every metric name when New is given an empty namespace.
+const DefaultNamespace = "cost_optimization"
+
+var sizeBuckets = []float64{10, 100, 1000, 10000, 100000, 1000000, 10000000}
+var durationBuckets = []float64{1e-6, 1e-5, 1e-4, 1e-3, 1e-2, 0.1, 1, 10}
+
+// errorTypes lists the error label values in exposition order; unknown errors count as "other".
+var errorTypes = []struct {
+	label string
+	err   error
+}{
+	{"empty_input", optimization.ErrEmptyInput},
+	{"invalid_number", optimization.ErrInvalidNumber},
+	{"invalid_format", optimization.ErrInvalidFormat},
+	{"infinite_cost", optimization.ErrInfiniteCost},
+	{"invalid_selection", optimization.ErrInvalidSelection},
+	{"invalid_penalty", optimization.ErrInvalidPenalty},
+	{"invalid_run", optimization.ErrInvalidRun},
+	{"invalid_constraint", optimization.ErrInvalidConstraint},
+	{"conflicting_options", optimization.ErrConflictingOptions},
+	{"unsupported_option", optimization.ErrUnsupportedOption},
+	{"audit_key", optimization.ErrAuditKey},
+	{"infeasible", optimization.ErrInfeasible},
+	{"search_limit", optimization.ErrSearchLimit},
+	{"problem_too_large", optimization.ErrProblemTooLarge},
+	{"summary_mismatch", optimization.ErrSummaryMismatch},
+	{"other", nil},
+}
+
+// Observer accumulates Stats. It is safe for concurrent use and implements http.Handler.
+type Observer struct {
+	namespace string
+
+	mu           sync.Mutex
+	calls        uint64
+	errors       []uint64 // indexed like errorTypes
+	inputSize    histogram
+	replacements histogram
+	duration     histogram
+}
+
+// New returns an empty Observer whose metric names start with namespace.
+func New(namespace string) *Observer {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	return &Observer{
+		namespace:    namespace,
+		errors:       make([]uint64, len(errorTypes)),
+		inputSize:    newHistogram(sizeBuckets),
+		replacements: newHistogram(sizeBuckets),
+		duration:     newHistogram(durationBuckets),
+	}
+}
+
+// Observe records one call.
+func (o *Observer) Observe(s optimization.Stats) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.calls++
+	if s.Err != nil {
+		o.errors[errorType(s.Err)]++
+	}
+	o.inputSize.observe(float64(s.N))
+	o.replacements.observe(float64(s.Replacements))
+	o.duration.observe(s.Duration.Seconds())
+}
+
+// ServeHTTP renders the metrics in the Prometheus text exposition format.
+func (o *Observer) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	o.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (o *Observer) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+
+	o.mu.Lock()
+	writeHeader(&b, o.name("calls_total"), "counter", "Number of optimization calls.")
+	fmt.Fprintf(&b, "%s %d\n", o.name("calls_total"), o.calls)
+
+	writeHeader(&b, o.name("errors_total"), "counter", "Number of failed optimization calls by error type.")
+	for i, t := range errorTypes {
+		fmt.Fprintf(&b, "%s{type=%q} %d\n", o.name("errors_total"), t.label, o.errors[i])
+	}
+
+	o.inputSize.write(&b, o.name("input_size"), "Number of costs per call.")
+	o.replacements.write(&b, o.name("replacements"), "Heap replacements per call.")
+	o.duration.write(&b, o.name("duration_seconds"), "Call duration in seconds.")
+	o.mu.Unlock()
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (o *Observer) name(metric string) string {
+	return o.namespace + "_" + metric
+}
+
+func errorType(err error) int {
+	for i, t := range errorTypes {
+		if t.err == nil || errors.Is(err, t.err) {
+			return i
+		}
+	}
+	return len(errorTypes) - 1
+}
+
+// histogram is a cumulative Prometheus histogram with fixed upper bounds.
+type histogram struct {
+	bounds []float64
+	counts []uint64 // counts[i] holds observations <= bounds[i] and > bounds[i-1]
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) histogram {
+	return histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *histogram) observe(v float64) {
+	h.count++
+	h.sum += v
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+			return
+		}
+	}
+}
+
+func (h *histogram) write(b *strings.Builder, name, help string) {
+	writeHeader(b, name, "histogram", help)
+	cumulative := uint64(0)
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		fmt.Fprintf(b, "%s_bucket{le=%q} %d\n", name, formatFloat(bound), cumulative)
+	}
+	fmt.Fprintf(b, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	fmt.Fprintf(b, "%s_sum %s\n", name, formatFloat(h.sum))
+	fmt.Fprintf(b, "%s_count %d\n", name, h.count)
+}
+
+func writeHeader(b *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,60 @@
+package promobserver
+
+import (
+	"flag"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	optimization "github.com/greyskp/cost_optimization/optimizer"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+func TestExpositionGolden(t *testing.T) {
+	o := New("")
+	o.Observe(optimization.Stats{N: 5, Replacements: 2, Duration: 3 * time.Microsecond})
+	o.Observe(optimization.Stats{N: 20000, Replacements: 1500, Duration: 2 * time.Millisecond})
+	o.Observe(optimization.Stats{N: 0, Duration: 500 * time.Nanosecond, Err: optimization.ErrEmptyInput})
+	o.Observe(optimization.Stats{N: 3, Duration: time.Microsecond, Err: optimization.ErrInvalidNumber})
+	o.Observe(optimization.Stats{N: 130, Duration: 4 * time.Millisecond, Err: optimization.ErrSearchLimit})
+
+	rec := httptest.NewRecorder()
+	o.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Fatalf("Content-Type is %q", ct)
+	}
+
+	golden := filepath.Join("testdata", "exposition.golden")
+	if *update {
+		if err := os.WriteFile(golden, rec.Body.Bytes(), 0o644); err != nil {
+			t.Fatalf("cannot update golden file: %v", err)
+		}
+	}
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("cannot read golden file: %v", err)
+	}
+	if rec.Body.String() != string(expected) {
+		t.Fatalf("exposition differs from %s:\n%s", golden, rec.Body.String())
+	}
+}
+
+func TestObserveFromOptimizer(t *testing.T) {
+	o := New("opt")
+	if _, err := optimization.CostOptimization([]float64{3, 1, 2}, optimization.WithObserver(o)); err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	optimization.CostOptimization(nil, optimization.WithObserver(o))
+	optimization.CostOptimizationWindow([]float64{1, 2}, optimization.WithNoAdjacent(), optimization.WithObserver(o))
+
+	if o.calls != 3 || o.errors[0] != 1 || o.inputSize.sum != 5 {
+		t.Fatalf("got calls=%d empty_input=%d input_size_sum=%v, expected 3, 1, 5", o.calls, o.errors[0], o.inputSize.sum)
+	}
+	// Errors returned wrapped still get their own label.
+	if i := errorType(optimization.ErrUnsupportedOption); errorTypes[i].label != "unsupported_option" || o.errors[i] != 1 {
+		t.Fatalf("got %s=%d, expected unsupported_option=1", errorTypes[i].label, o.errors[i])
+	}
+}
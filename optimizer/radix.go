@@ -0,0 +1,95 @@
+package optimization
+
+import "math"
+
+const radixDigitBits = 16
+const radixBuckets = 1 << radixDigitBits
+
+// floatKey maps a non-negative cost to an unsigned key with the same ordering.
+// -0 is folded into +0 so both compare equal, as they do in the heap.
+func floatKey(value float64) uint64 {
+	if value == 0 {
+		return 0
+	}
+	return math.Float64bits(value)
+}
+
+// keyFloat is the inverse of floatKey.
+func keyFloat(key uint64) float64 {
+	return math.Float64frombits(key)
+}
+
+// radixSelect finds the k-th smallest (1-based) key visited by scan and the number of keys strictly below it.
+// Each of the four counting passes re-runs scan, so memory stays fixed regardless of how many keys there are.
+func radixSelect(k int, scan func(visit func(key uint64)) error) (uint64, int, error) {
+	var prefix uint64
+	below := 0
+	counts := make([]int, radixBuckets)
+
+	for shift := 64 - radixDigitBits; shift >= 0; shift -= radixDigitBits {
+		clear(counts)
+		high := shift + radixDigitBits
+		err := scan(func(key uint64) {
+			if high < 64 && key>>high != prefix>>high {
+				return
+			}
+			counts[(key>>shift)&(radixBuckets-1)]++
+		})
+		if err != nil {
+			return 0, 0, err
+		}
+
+		// Walk the buckets until the k-th key falls inside one of them.
+		digit := 0
+		for ; digit < radixBuckets-1 && counts[digit] < k; digit++ {
+			k -= counts[digit]
+			below += counts[digit]
+		}
+		prefix |= uint64(digit) << shift
+	}
+
+	return prefix, below, nil
+}
+
+// radixSelectKeys finds the k-th smallest (1-based) of keys and the number of keys strictly below it,
+// using 8-bit counting passes that narrow keys in place. keys is used as scratch space.
+func radixSelectKeys(keys []uint64, k int) (uint64, int) {
+	var all uint64
+	for _, key := range keys {
+		all |= key
+	}
+
+	// Digits above the highest bit set in any key are zero everywhere and can be skipped.
+	shift := 0
+	for shift+8 < 64 && all>>(shift+8) != 0 {
+		shift += 8
+	}
+
+	var prefix uint64
+	below := 0
+	var counts [256]int
+	for ; shift >= 0; shift -= 8 {
+		clear(counts[:])
+		for _, key := range keys {
+			counts[(key>>shift)&0xFF]++
+		}
+
+		digit := 0
+		for ; digit < 255 && counts[digit] < k; digit++ {
+			k -= counts[digit]
+			below += counts[digit]
+		}
+		prefix |= uint64(digit) << shift
+
+		// Keep only the keys that share the chosen digit for the next pass.
+		kept := keys[:0]
+		for _, key := range keys {
+			if (key>>shift)&0xFF == uint64(digit) {
+				kept = append(kept, key)
+			}
+		}
+		keys = kept
+	}
+
+	return prefix, below
+}
@@ -0,0 +1,389 @@
+package optimization
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+const recordMagic = "COSR"
+const recordVersion = 1
+
+// RecorderConfig limits what a Recorder captures.
+type RecorderConfig struct {
+	// SampleEvery records one call in every SampleEvery. Zero or one records every call.
+	SampleEvery int
+	// MaxElements skips calls with more costs than this. Zero means no limit.
+	MaxElements int
+	// MaxBytes stops recording once the output would grow beyond this size. Zero means no limit.
+	MaxBytes int64
+}
+
+// Recorder captures the inputs, options and output of CostOptimization calls (see WithRecorder)
+// in a compact binary format that Replay can re-run. It is safe for concurrent use.
+//
+// The stream starts with a "COSR" header and a version byte, followed by one record per call:
+// the costs (uvarint count, little-endian float64 values), the options (uvarint count of
+// uvarint-length-prefixed key/value strings), the error message (empty on success) and, on
+// success, the selection packed as one bit per cost.
+type Recorder struct {
+	cfg  RecorderConfig
+	seen atomic.Uint64
+
+	mu       sync.Mutex
+	w        io.Writer
+	written  int64
+	recorded int
+	skipped  int
+	err      error
+}
+
+// NewRecorder returns a Recorder writing to w.
+func NewRecorder(w io.Writer, cfg RecorderConfig) *Recorder {
+	return &Recorder{w: w, cfg: cfg}
+}
+
+// Recorded returns the number of calls written.
+func (r *Recorder) Recorded() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.recorded
+}
+
+// Skipped returns the number of sampled calls that were not written because of a size limit.
+func (r *Recorder) Skipped() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.skipped
+}
+
+// Err returns the first write error; recording stops after it.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// record writes one call if it is sampled and fits the limits.
+func (r *Recorder) record(prices []float64, cfg options, out []int, callErr error) {
+	if n := r.seen.Add(1); r.cfg.SampleEvery > 1 && (n-1)%uint64(r.cfg.SampleEvery) != 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return
+	}
+	if r.cfg.MaxElements > 0 && len(prices) > r.cfg.MaxElements {
+		r.skipped++
+		return
+	}
+
+	var buf []byte
+	if r.written == 0 {
+		buf = append(buf, recordMagic...)
+		buf = append(buf, recordVersion)
+	}
+	buf = appendRecord(buf, Recording{Costs: prices, Options: cfg.describe(), Output: out, Err: errorMessage(callErr)})
+
+	if r.cfg.MaxBytes > 0 && r.written+int64(len(buf)) > r.cfg.MaxBytes {
+		r.skipped++
+		return
+	}
+	n, err := r.w.Write(buf)
+	r.written += int64(n)
+	if err != nil {
+		r.err = err
+		return
+	}
+	r.recorded++
+}
+
+// Recording is one call read back from a Recorder stream.
+type Recording struct {
+	Costs   []float64
+	Options map[string]string
+	Output  []int  // nil when the call failed
+	Err     string // error message, empty on success
+}
+
+// ReadRecordings decodes every call of a Recorder stream.
+func ReadRecordings(r io.Reader) ([]Recording, error) {
+	in := bufio.NewReader(r)
+
+	header := make([]byte, len(recordMagic)+1)
+	if _, err := io.ReadFull(in, header); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: recording header missing", ErrInvalidFormat)
+	}
+	if string(header[:len(recordMagic)]) != recordMagic || header[len(recordMagic)] != recordVersion {
+		return nil, fmt.Errorf("%w: not a recording", ErrInvalidFormat)
+	}
+
+	var recordings []Recording
+	for {
+		rec, err := readRecord(in)
+		if err == io.EOF {
+			return recordings, nil
+		}
+		if err != nil {
+			return recordings, fmt.Errorf("%w: record %d: %v", ErrInvalidFormat, len(recordings), err)
+		}
+		recordings = append(recordings, rec)
+	}
+}
+
+// Divergence describes a recorded call whose replay did not match.
+type Divergence struct {
+	Record      int
+	Indices     []int // indices whose selection differs
+	RecordedErr string
+	ReplayedErr string
+}
+
+// ReplayReport summarizes a Replay run.
+type ReplayReport struct {
+	Records     int
+	Divergences []Divergence
+}
+
+// Replay re-runs every call of a Recorder stream with the recorded options (plus opts, e.g. an
+// observer) and reports the calls whose output or error differs from the recording.
+func Replay(r io.Reader, opts ...Option) (ReplayReport, error) {
+	recordings, err := ReadRecordings(r)
+	report := ReplayReport{Records: len(recordings)}
+	if err != nil {
+		return report, err
+	}
+
+	for i, rec := range recordings {
+		recorded, err := optionsFromDescription(rec.Options)
+		if err != nil {
+			return report, fmt.Errorf("record %d: %w", i, err)
+		}
+
+		out, callErr := CostOptimization(rec.Costs, append(recorded, opts...)...)
+
+		d := Divergence{Record: i, RecordedErr: rec.Err, ReplayedErr: errorMessage(callErr)}
+		for j := range max(len(out), len(rec.Output)) {
+			if j >= len(out) || j >= len(rec.Output) || out[j] != rec.Output[j] {
+				d.Indices = append(d.Indices, j)
+			}
+		}
+		if d.RecordedErr != d.ReplayedErr || len(d.Indices) > 0 {
+			report.Divergences = append(report.Divergences, d)
+		}
+	}
+	return report, nil
+}
+
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func appendRecord(buf []byte, rec Recording) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(rec.Costs)))
+	for _, c := range rec.Costs {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(c))
+	}
+
+	keys := slices.Sorted(maps.Keys(rec.Options))
+	buf = binary.AppendUvarint(buf, uint64(len(keys)))
+	for _, k := range keys {
+		buf = appendString(buf, k)
+		buf = appendString(buf, rec.Options[k])
+	}
+
+	buf = appendString(buf, rec.Err)
+	if rec.Err == "" {
+		packed := make([]byte, (len(rec.Output)+7)/8)
+		for i, v := range rec.Output {
+			if v == 1 {
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+		buf = append(buf, packed...)
+	}
+	return buf
+}
+
+// recordChunk is the number of costs readRecord decodes at a time.
+const recordChunk = 1 << 16
+
+func readRecord(in *bufio.Reader) (Recording, error) {
+	var rec Recording
+
+	n, err := binary.ReadUvarint(in)
+	if err != nil {
+		return rec, err // io.EOF here is a clean end of stream
+	}
+	if n > math.MaxInt32 {
+		return rec, errors.New("cost count out of range")
+	}
+	// Grow with the data actually read, so a corrupt count cannot allocate gigabytes up front.
+	rec.Costs = make([]float64, 0, min(n, recordChunk))
+	chunk := make([]float64, min(n, recordChunk))
+	for left := int(n); left > 0; left -= len(chunk) {
+		chunk = chunk[:min(left, recordChunk)]
+		if err := binary.Read(in, binary.LittleEndian, chunk); err != nil {
+			return rec, unexpected(err)
+		}
+		rec.Costs = append(rec.Costs, chunk...)
+	}
+
+	count, err := binary.ReadUvarint(in)
+	if err != nil {
+		return rec, unexpected(err)
+	}
+	rec.Options = make(map[string]string, min(count, 64))
+	for range count {
+		k, err := readString(in)
+		if err != nil {
+			return rec, err
+		}
+		v, err := readString(in)
+		if err != nil {
+			return rec, err
+		}
+		rec.Options[k] = v
+	}
+
+	if rec.Err, err = readString(in); err != nil {
+		return rec, err
+	}
+	if rec.Err == "" {
+		packed := make([]byte, (n+7)/8)
+		if _, err := io.ReadFull(in, packed); err != nil {
+			return rec, unexpected(err)
+		}
+		rec.Output = make([]int, n)
+		for i := range rec.Output {
+			rec.Output[i] = int(packed[i/8]>>(i%8)) & 1
+		}
+	}
+	return rec, nil
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func readString(in *bufio.Reader) (string, error) {
+	n, err := binary.ReadUvarint(in)
+	if err != nil {
+		return "", unexpected(err)
+	}
+	if n > 1<<20 {
+		return "", errors.New("string length out of range")
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(in, b); err != nil {
+		return "", unexpected(err)
+	}
+	return string(b), nil
+}
+
+// unexpected turns an EOF inside a record into io.ErrUnexpectedEOF.
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// describe returns the options that influence the output of CostOptimization, as strings.
+func (o options) describe() map[string]string {
+	desc := map[string]string{
+		"solver": o.solver.Name(),
+	}
+	if o.previous != nil {
+		desc["previous_selection"] = formatSelection(o.previous)
+		desc["switch_penalty"] = strconv.FormatFloat(o.penalty, 'g', -1, 64)
+	}
+	if o.runCoverage != 0 {
+		desc["run_coverage"] = strconv.Itoa(o.runCoverage)
+	}
+	if o.noAdjacent {
+		desc["no_adjacent"] = "true"
+	}
+	if len(o.conflicts) > 0 {
+		desc["conflicts"] = formatConflicts(o.conflicts)
+	}
+	if len(o.implications) > 0 {
+		desc["implications"] = formatImplications(o.implications)
+	}
+	return desc
+}
+
+// optionsFromDescription rebuilds the options described by describe.
+func optionsFromDescription(desc map[string]string) ([]Option, error) {
+	var opts []Option
+	for key, value := range desc {
+		switch key {
+		case "solver":
+			solver, ok := solverByName(value)
+			if !ok {
+				return nil, fmt.Errorf("%w: unknown solver %s", ErrInvalidFormat, strconv.Quote(value))
+			}
+			opts = append(opts, WithSolver(solver))
+		case "previous_selection":
+			prev, err := parseSelection(value)
+			if err != nil {
+				return nil, err
+			}
+			penalty, err := strconv.ParseFloat(desc["switch_penalty"], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: switch penalty %s", ErrInvalidFormat, strconv.Quote(desc["switch_penalty"]))
+			}
+			opts = append(opts, WithPreviousSelection(prev, penalty))
+		case "switch_penalty":
+			// Read together with previous_selection.
+		case "run_coverage":
+			m, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: run coverage %s", ErrInvalidFormat, strconv.Quote(value))
+			}
+			opts = append(opts, WithRunCoverage(m))
+		case "no_adjacent":
+			if value != "true" {
+				return nil, fmt.Errorf("%w: no_adjacent %s", ErrInvalidFormat, strconv.Quote(value))
+			}
+			opts = append(opts, WithNoAdjacent())
+		case "conflicts":
+			pairs, err := parsePairs(value, "/")
+			if err != nil {
+				return nil, err
+			}
+			for _, p := range pairs {
+				opts = append(opts, WithConflicts(Conflict{p[0], p[1]}))
+			}
+		case "implications":
+			pairs, err := parsePairs(value, ">")
+			if err != nil {
+				return nil, err
+			}
+			for _, p := range pairs {
+				opts = append(opts, WithImplications(Implication{p[0], p[1]}))
+			}
+		default:
+			return nil, fmt.Errorf("%w: unknown option %s", ErrInvalidFormat, strconv.Quote(key))
+		}
+	}
+	return opts, nil
+}
@@ -0,0 +1,106 @@
+package optimization
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf, RecorderConfig{})
+
+	inputs := [][]float64{
+		{-10, 17, 15, -40, 20},
+		randFloats(-100.0, 500.0, 301),
+		{},
+	}
+	for _, costs := range inputs {
+		CostOptimization(costs, WithRecorder(rec), WithSolver(QuickselectSolver{}))
+	}
+	if rec.Recorded() != 3 || rec.Err() != nil {
+		t.Fatalf("recorded %d calls (err %v), expected 3", rec.Recorded(), rec.Err())
+	}
+
+	recordings, err := ReadRecordings(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadRecordings returned unexpected error: %v", err)
+	}
+	if !slices.Equal(recordings[1].Costs, inputs[1]) || recordings[1].Options["solver"] != "quickselect" {
+		t.Fatalf("recording does not match the call: options %v", recordings[1].Options)
+	}
+	if recordings[2].Err != ErrEmptyInput.Error() || recordings[2].Output != nil {
+		t.Fatalf("failed call recorded as err=%q output=%v", recordings[2].Err, recordings[2].Output)
+	}
+
+	report, err := Replay(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Replay returned unexpected error: %v", err)
+	}
+	if report.Records != 3 || len(report.Divergences) != 0 {
+		t.Fatalf("got %d records and divergences %+v, expected 3 and none", report.Records, report.Divergences)
+	}
+
+	// Flip the first selection bit of the first record: 1 cost count byte + 5 costs + options + error.
+	tampered := slices.Clone(buf.Bytes())
+	offset := len(recordMagic) + 1 + 1 + 5*8 + 1 + 1 + len("solver") + 1 + len("quickselect") + 1
+	tampered[offset] ^= 1
+	report, err = Replay(bytes.NewReader(tampered))
+	if err != nil {
+		t.Fatalf("Replay returned unexpected error: %v", err)
+	}
+	if len(report.Divergences) != 1 || report.Divergences[0].Record != 0 || !slices.Equal(report.Divergences[0].Indices, []int{0}) {
+		t.Fatalf("got divergences %+v, expected index 0 of record 0", report.Divergences)
+	}
+}
+
+func TestRecorderLimits(t *testing.T) {
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf, RecorderConfig{SampleEvery: 2, MaxElements: 10})
+	for range 4 {
+		CostOptimization([]float64{1, 2, 3}, WithRecorder(rec))
+	}
+	CostOptimization(randFloats(0, 1, 11), WithRecorder(rec))
+	if rec.Recorded() != 2 || rec.Skipped() != 1 {
+		t.Fatalf("recorded %d and skipped %d, expected 2 and 1", rec.Recorded(), rec.Skipped())
+	}
+
+	buf.Reset()
+	rec = NewRecorder(&buf, RecorderConfig{MaxBytes: 64})
+	for range 3 {
+		CostOptimization([]float64{1, 2, 3}, WithRecorder(rec))
+	}
+	if rec.Recorded() != 1 || int64(buf.Len()) > 64 {
+		t.Fatalf("recorded %d calls in %d bytes, expected 1 within 64 bytes", rec.Recorded(), buf.Len())
+	}
+}
+
+func TestReplayMalformed(t *testing.T) {
+	if _, err := Replay(bytes.NewReader([]byte("nope!"))); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("got %v, expected %v", err, ErrInvalidFormat)
+	}
+	if _, err := Replay(bytes.NewReader([]byte("COSR\x01\x03\x00"))); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("got %v, expected %v", err, ErrInvalidFormat)
+	}
+}
+
+func TestReadRecordingsHugeCount(t *testing.T) {
+	data := binary.AppendUvarint([]byte("COSR\x01"), math.MaxInt32)
+	data = append(data, make([]byte, 16)...)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := ReadRecordings(bytes.NewReader(data))
+	runtime.ReadMemStats(&after)
+
+	if !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("got %v, expected %v", err, ErrInvalidFormat)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 16<<20 {
+		t.Fatalf("truncated recording allocated %d bytes", allocated)
+	}
+}
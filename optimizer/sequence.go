@@ -0,0 +1,258 @@
+package optimization
+
+import (
+	"errors"
+	"math"
+)
+
+var ErrInfeasible = errors.New("constraints cannot all be satisfied")
+var ErrProblemTooLarge = errors.New("problem is too large for the exact solver")
+var ErrInvalidRun = errors.New("run length must be at least 1")
+
+const PathSequence Path = "sequence" // spacing constraints solved by dynamic programming
+
+// maxSequenceStates bounds the dynamic program behind the spacing constraints, which keeps a value
+// and a count range, 16 bytes, per state: (positions+1) × gap lengths.
+const maxSequenceStates = 1 << 24
+
+// sequenceTolerance is the relative difference under which two totals of the dynamic program are
+// treated as tied, so that rounding cannot hide selections of equal cost but different sizes.
+const sequenceTolerance = 1e-9
+
+// maxMultiplierSteps bounds the bisection on the multiplier; it stops earlier once the bracket is
+// too narrow to change the selection.
+const maxMultiplierSteps = 200
+
+// WithRunCoverage requires every run of m consecutive items to contain at least one selected item,
+// e.g. for periodic sampling. m = 1 selects everything.
+func WithRunCoverage(m int) Option {
+	return func(opt *options) {
+		// Zero means unset, so invalid lengths are kept negative to fail with ErrInvalidRun.
+		if m < 1 {
+			m = -1
+		}
+		opt.runCoverage = m
+	}
+}
+
+// WithNoAdjacent forbids selecting two neighbouring items.
+func WithNoAdjacent() Option {
+	return func(opt *options) {
+		opt.noAdjacent = true
+	}
+}
+
+// sequenceConstrained reports whether the spacing constraints can bind on n items. A selection
+// leaves at most n-⌈n/2⌉ items out, so longer runs never need covering.
+func (o options) sequenceConstrained(n int) bool {
+	return o.noAdjacent || o.runCoverage < 0 || (o.runCoverage > 0 && o.runCoverage <= n-requiredCount(n))
+}
+
+// selectSequence writes into res the cheapest selection of at least ⌈n/2⌉ costs that satisfies the
+// spacing constraints of cfg.
+//
+// Every constraint, the count included, sums the flags of a contiguous range of items, so the
+// constraint matrix is an interval matrix. Such a matrix is totally unimodular, which makes a
+// Lagrangian multiplier λ on the count exact: for a given λ, a dynamic program over (position,
+// length of the current run of unselected items) finds the selections minimizing the total of
+// cost−λ in O(n · gap) time, along with the range of sizes these selections have. That range only
+// grows with λ, so λ is bisected until it covers ⌈n/2⌉, and a selection of that size is rebuilt
+// from the ranges kept for every state.
+func selectSequence(prices []float64, res []int, cfg options) error {
+	n := len(prices)
+	need := requiredCount(n)
+
+	if cfg.runCoverage < 0 {
+		return ErrInvalidRun
+	}
+	sumAbs := 0.0
+	for _, value := range prices {
+		if math.IsInf(value, 0) {
+			return ErrInfiniteCost
+		}
+		sumAbs += math.Abs(value)
+	}
+
+	dp := sequenceDP{prices: prices, noAdjacent: cfg.noAdjacent, run: cfg.runCoverage, gaps: 2}
+	if dp.run > n-need {
+		dp.run = 0
+	}
+	// Without a run limit only "last item selected or not" matters.
+	if dp.run > 0 {
+		dp.gaps = dp.run
+	}
+	if n+1 > maxSequenceStates/dp.gaps {
+		return ErrProblemTooLarge
+	}
+
+	lambda := 0.0
+	if end, ok := dp.solve(0, nil); !ok {
+		return ErrInfeasible
+	} else if int(end.hi) < need {
+		// Beyond the largest possible change in total cost, every extra item pays off, so the
+		// largest selections are optimal; if even they are too small, none is large enough.
+		lo, hi := 0.0, sumAbs+1
+		if end, _ := dp.solve(hi, nil); int(end.hi) < need {
+			return ErrInfeasible
+		}
+		for range maxMultiplierSteps {
+			mid := lo + (hi-lo)/2
+			// Past this width, selections of ⌈n/2⌉ items tie with the optimum at hi.
+			if mid <= lo || mid >= hi || (hi-lo)*float64(n) <= dp.tolerance(hi)/2 {
+				break
+			}
+			if end, _ := dp.solve(mid, nil); int(end.hi) >= need {
+				hi = mid
+			} else {
+				lo = mid
+			}
+		}
+		lambda = hi
+	}
+
+	table := make([]sequenceState, (n+1)*dp.gaps)
+	end, _ := dp.solve(lambda, table)
+	dp.rebuild(lambda, table, end, need, res)
+	return nil
+}
+
+// sequenceState is the best total of cost−λ over the selections reaching a state, with the range
+// of their sizes. Selections within the tolerance of the best are counted as tied.
+type sequenceState struct {
+	total  float64
+	lo, hi int32
+}
+
+// relax offers a selection of the given total and sizes to s. The total of s is that of the
+// first selection of its tie, so the tie cannot drift.
+func (s *sequenceState) relax(total float64, lo, hi int32, tol float64) {
+	switch {
+	case total < s.total-tol:
+		*s = sequenceState{total, lo, hi}
+	case total <= s.total+tol:
+		s.lo, s.hi = min(s.lo, lo), max(s.hi, hi)
+	}
+}
+
+// sequenceDP is the dynamic program behind selectSequence. State g, for g < gaps, is the length of
+// the current run of unselected items; without a run limit, state 1 only means the last item was
+// not selected.
+type sequenceDP struct {
+	prices     []float64
+	noAdjacent bool
+	run        int
+	gaps       int
+}
+
+// tolerance returns the largest difference of totals that counts as a tie for multiplier lambda.
+func (d sequenceDP) tolerance(lambda float64) float64 {
+	scale := 0.0
+	for _, value := range d.prices {
+		scale += math.Abs(value - lambda)
+	}
+	return sequenceTolerance * scale
+}
+
+// solve runs the dynamic program for multiplier lambda and returns the best final state, tied
+// states merged. When table is not nil, it receives every layer of states, layer i being the states
+// before item i. ok is false when no selection satisfies the constraints.
+func (d sequenceDP) solve(lambda float64, table []sequenceState) (end sequenceState, ok bool) {
+	tol := d.tolerance(lambda)
+	cur := make([]sequenceState, d.gaps)
+	next := make([]sequenceState, d.gaps)
+	unreached := sequenceState{total: math.Inf(1), lo: math.MaxInt32, hi: -1}
+	for g := range cur {
+		cur[g] = unreached
+	}
+	cur[0] = sequenceState{}
+
+	for i, value := range d.prices {
+		if table != nil {
+			copy(table[i*d.gaps:], cur)
+		}
+		for g := range next {
+			next[g] = unreached
+		}
+		for g, s := range cur {
+			if math.IsInf(s.total, 1) {
+				continue
+			}
+			if d.canSelect(i, g) {
+				next[0].relax(s.total+value-lambda, s.lo+1, s.hi+1, tol)
+			}
+			if g, ok := d.skip(g); ok {
+				next[g].relax(s.total, s.lo, s.hi, tol)
+			}
+		}
+		cur, next = next, cur
+	}
+	if table != nil {
+		copy(table[len(d.prices)*d.gaps:], cur)
+	}
+
+	end = unreached
+	for _, s := range cur {
+		if !math.IsInf(s.total, 1) {
+			end.relax(s.total, s.lo, s.hi, tol)
+		}
+	}
+	return end, !math.IsInf(end.total, 1)
+}
+
+// canSelect reports whether item i may be selected from state g.
+func (d sequenceDP) canSelect(i, g int) bool {
+	return !d.noAdjacent || g > 0 || i == 0
+}
+
+// skip returns the state after leaving an item out in state g, and false when that breaks the run limit.
+func (d sequenceDP) skip(g int) (int, bool) {
+	if d.run == 0 {
+		return 1, true
+	}
+	return g + 1, g+1 < d.run
+}
+
+// rebuild walks table back from the final layer and writes into res a selection of the best total
+// for lambda whose size is need, or the smallest size above it when need is out of reach.
+func (d sequenceDP) rebuild(lambda float64, table []sequenceState, end sequenceState, need int, res []int) {
+	tol := d.tolerance(lambda)
+	n := len(d.prices)
+	layer := func(i int) []sequenceState { return table[i*d.gaps : (i+1)*d.gaps] }
+
+	// pick returns the state of candidates, all reaching the same total plus step, whose range
+	// holds target; failing that, the one whose range starts closest above it.
+	target := max(int32(need), end.lo)
+	pick := func(candidates []sequenceState, allowed func(int) bool, reference, step float64) int {
+		best := -1
+		for g, s := range candidates {
+			if !allowed(g) || math.IsInf(s.total, 1) || math.Abs(s.total+step-reference) > tol || s.hi < target {
+				continue
+			}
+			if s.lo <= target {
+				return g
+			}
+			if best < 0 || s.lo < candidates[best].lo {
+				best = g
+			}
+		}
+		return best
+	}
+
+	state := pick(layer(n), func(int) bool { return true }, end.total, 0)
+	for i := n - 1; i >= 0; i-- {
+		s := layer(i + 1)[state]
+		target = max(min(target, s.hi), s.lo)
+		if state == 0 {
+			res[i] = 1
+			target--
+			state = pick(layer(i), func(g int) bool { return d.canSelect(i, g) }, s.total, d.prices[i]-lambda)
+		} else {
+			res[i] = 0
+			from := state
+			state = pick(layer(i), func(g int) bool {
+				next, ok := d.skip(g)
+				return ok && next == from
+			}, s.total, 0)
+		}
+	}
+}
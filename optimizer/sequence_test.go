@@ -0,0 +1,195 @@
+package optimization
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestRunCoverage(t *testing.T) {
+	costs := []float64{1, 2, 9, 8, 9, 9, 2, 1}
+	stats := &statsRecorder{}
+
+	out, err := CostOptimization(costs, WithRunCoverage(3), WithObserver(stats))
+	if err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	// The four cheap items leave a run of four expensive ones, so the 8 replaces the first 2.
+	if expected := []int{1, 0, 0, 1, 0, 0, 1, 1}; !slices.Equal(out, expected) {
+		t.Fatalf("output %v, expected %v", out, expected)
+	}
+	if stats.last.Path != PathSequence || stats.last.SelectedCount != 4 || stats.last.TotalCost != 12 || stats.last.CutoffCost != 8 {
+		t.Fatalf("unexpected stats %+v", stats.last)
+	}
+
+	// Runs longer than the items that can be left out never bind.
+	CostOptimization(costs, WithRunCoverage(5), WithObserver(stats))
+	if stats.last.Path == PathSequence {
+		t.Fatal("a vacuous run constraint used the dynamic program")
+	}
+}
+
+func TestNoAdjacent(t *testing.T) {
+	costs := []float64{-5, -5, 3, 1, 2, 8}
+	out, err := CostOptimization(costs, WithNoAdjacent())
+	if err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	if expected := []int{1, 0, 1, 0, 1, 0}; !slices.Equal(out, expected) {
+		t.Fatalf("output %v, expected %v", out, expected)
+	}
+}
+
+func TestSequenceIsOptimal(t *testing.T) {
+	r := rand.New(rand.NewPCG(49, 3))
+	for range 500 {
+		costs := make([]float64, 1+r.IntN(12))
+		for i := range costs {
+			costs[i] = float64(r.IntN(21) - 8)
+		}
+		run, noAdjacent := r.IntN(5), r.IntN(2) == 1
+		if run == 0 && !noAdjacent {
+			noAdjacent = true
+		}
+		var opts []Option
+		if run > 0 {
+			opts = append(opts, WithRunCoverage(run))
+		}
+		if noAdjacent {
+			opts = append(opts, WithNoAdjacent())
+		}
+
+		best, feasible := bruteForceSequence(costs, run, noAdjacent)
+		out, err := CostOptimization(costs, opts...)
+		if !feasible {
+			if !errors.Is(err, ErrInfeasible) {
+				t.Fatalf("costs %v run %d noAdjacent %v: expected ErrInfeasible, got %v", costs, run, noAdjacent, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("CostOptimization returned unexpected error: %v", err)
+		}
+		if !satisfiesSequence(out, run, noAdjacent) {
+			t.Fatalf("costs %v run %d noAdjacent %v: output %v breaks the constraints", costs, run, noAdjacent, out)
+		}
+		if total, _ := TotalCost(costs, out); total != best {
+			t.Fatalf("costs %v run %d noAdjacent %v: total %v, optimum %v", costs, run, noAdjacent, total, best)
+		}
+	}
+}
+
+func TestSequenceLarge(t *testing.T) {
+	// With an even n, the selections of n/2 non-adjacent items take the even positions up to some
+	// point and the odd ones after it, so the optimum is the best of n/2+1 prefix-sum splits.
+	const n = 200000
+	r := rand.New(rand.NewPCG(49, 4))
+	costs := make([]float64, n)
+	for i := range costs {
+		costs[i] = float64(r.IntN(2001) - 500)
+	}
+
+	// even[j] sums the costs at even positions below 2j, odd[j] those at odd positions from 2j+1.
+	even := make([]float64, n/2+1)
+	odd := make([]float64, n/2+1)
+	for j := range n / 2 {
+		even[j+1] = even[j] + costs[2*j]
+		odd[n/2-1-j] = odd[n/2-j] + costs[n-1-2*j]
+	}
+	best := math.Inf(1)
+	for j := range n/2 + 1 {
+		best = min(best, even[j]+odd[j])
+	}
+
+	out, err := CostOptimization(costs, WithNoAdjacent())
+	if err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	if !satisfiesSequence(out, 0, true) {
+		t.Fatal("output breaks the constraints")
+	}
+	if total, _ := TotalCost(costs, out); total != best {
+		t.Fatalf("total %v, optimum %v", total, best)
+	}
+
+	// Covering every run of two as well leaves only the two alternating patterns.
+	out, err = CostOptimization(costs, WithNoAdjacent(), WithRunCoverage(2))
+	if err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	if total, _ := TotalCost(costs, out); total != min(even[n/2], odd[0]) {
+		t.Fatalf("total %v, optimum %v", total, min(even[n/2], odd[0]))
+	}
+}
+
+func TestSequenceErrors(t *testing.T) {
+	costs := []float64{1, 2, 3}
+	cases := []struct {
+		costs []float64
+		opts  []Option
+		err   error
+	}{
+		{costs, []Option{WithRunCoverage(1), WithNoAdjacent()}, ErrInfeasible},
+		{costs, []Option{WithRunCoverage(0)}, ErrInvalidRun},
+		{costs, []Option{WithRunCoverage(-2)}, ErrInvalidRun},
+		{[]float64{1, math.Inf(1), 3}, []Option{WithNoAdjacent()}, ErrInfiniteCost},
+		{make([]float64, 10000), []Option{WithRunCoverage(5000)}, ErrProblemTooLarge},
+	}
+	for _, c := range cases {
+		if _, err := CostOptimization(c.costs, c.opts...); !errors.Is(err, c.err) {
+			t.Errorf("costs of length %d: expected %v, got %v", len(c.costs), c.err, err)
+		}
+	}
+}
+
+func TestSequenceAudit(t *testing.T) {
+	costs := []float64{1, 1, 9, 9, 9, 9, 1, 1}
+	var rec AuditRecord
+	out, err := CostOptimization(costs, WithRunCoverage(3), WithNoAdjacent(), WithAudit(&rec, auditKey))
+	if err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	if rec.Options["run_coverage"] != "3" || rec.Options["no_adjacent"] != "true" {
+		t.Fatalf("options not described: %v", rec.Options)
+	}
+	if err := VerifyAudit(rec, auditKey, costs, out); err != nil {
+		t.Fatalf("VerifyAudit rejected a constrained decision: %v", err)
+	}
+}
+
+func satisfiesSequence(sel []int, run int, noAdjacent bool) bool {
+	if countOnes(sel) < requiredCount(len(sel)) {
+		return false
+	}
+	gap := 0
+	for i, v := range sel {
+		if noAdjacent && i > 0 && v == 1 && sel[i-1] == 1 {
+			return false
+		}
+		if v == 1 {
+			gap = 0
+		} else if gap++; run > 0 && gap >= run {
+			return false
+		}
+	}
+	return true
+}
+
+// bruteForceSequence returns the smallest total over every selection meeting the constraints.
+func bruteForceSequence(costs []float64, run int, noAdjacent bool) (float64, bool) {
+	n := len(costs)
+	best, feasible := math.Inf(1), false
+	sel := make([]int, n)
+	for mask := range 1 << n {
+		for i := range sel {
+			sel[i] = mask >> i & 1
+		}
+		if satisfiesSequence(sel, run, noAdjacent) {
+			total, _ := TotalCost(costs, sel)
+			best, feasible = min(best, total), true
+		}
+	}
+	return best, feasible
+}
@@ -0,0 +1,120 @@
+// Package slogobserver provides an optimization.Observer that writes Stats as structured log/slog
+// records from a background goroutine, so logging never blocks the optimizer's caller.
+package slogobserver
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	optimization "github.com/greyskp/cost_optimization/optimizer"
+)
+
+const defaultBufferSize = 256
+
+// Options configures an Observer. The zero value logs every call at Info.
+type Options struct {
+	// Level is used for successful calls under SlowThreshold.
+	Level slog.Level
+	// SlowThreshold escalates calls that take at least this long to Warn. Zero disables it.
+	SlowThreshold time.Duration
+	// SampleEvery logs only one in every SampleEvery regular calls; failed and slow calls are
+	// always logged. Zero or one logs everything.
+	SampleEvery int
+	// BufferSize bounds the records waiting to be written; extra records are dropped. Defaults to 256.
+	BufferSize int
+	// Message is the log message, "cost optimization" by default.
+	Message string
+}
+
+// Observer logs Stats through a slog.Logger.
+type Observer struct {
+	logger *slog.Logger
+	opts   Options
+	seen   atomic.Uint64
+	async  *optimization.AsyncObserver
+}
+
+// New starts an Observer writing to logger. Close must be called to release its goroutine.
+func New(logger *slog.Logger, opts Options) *Observer {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaultBufferSize
+	}
+	if opts.Message == "" {
+		opts.Message = "cost optimization"
+	}
+
+	o := &Observer{logger: logger, opts: opts}
+	o.async = optimization.NewAsyncObserver(optimization.ObserverFunc(o.write), opts.BufferSize)
+	return o
+}
+
+// Observe queues s for logging. It never blocks: when the buffer is full the record is dropped.
+func (o *Observer) Observe(s optimization.Stats) {
+	level, escalated := o.level(s)
+	if !escalated {
+		if n := o.seen.Add(1); o.opts.SampleEvery > 1 && (n-1)%uint64(o.opts.SampleEvery) != 0 {
+			return
+		}
+	}
+
+	if !o.logger.Enabled(context.Background(), level) {
+		return
+	}
+	o.async.Observe(s)
+}
+
+// Dropped returns how many records were discarded because the buffer was full.
+func (o *Observer) Dropped() uint64 {
+	return o.async.Dropped()
+}
+
+// Close writes the records still buffered and stops the background goroutine.
+func (o *Observer) Close() {
+	o.async.Close()
+}
+
+// level returns the level for s and whether it was escalated above Options.Level.
+func (o *Observer) level(s optimization.Stats) (slog.Level, bool) {
+	switch {
+	case s.Err != nil:
+		return slog.LevelError, true
+	case o.opts.SlowThreshold > 0 && s.Duration >= o.opts.SlowThreshold:
+		return slog.LevelWarn, true
+	}
+	return o.opts.Level, false
+}
+
+func (o *Observer) write(s optimization.Stats) {
+	level, _ := o.level(s)
+	o.logger.LogAttrs(context.Background(), level, o.opts.Message, Attrs(s)...)
+}
+
+// Attrs converts s to slog attributes.
+func Attrs(s optimization.Stats) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.Int("n", s.N),
+		slog.Int("selected", s.SelectedCount),
+		slog.Int("left_to_fill", s.LeftToFill),
+		slog.Int("replacements", s.Replacements),
+		slog.Int("negatives", s.Negatives),
+		slog.Duration("duration", s.Duration),
+		slog.String("path", string(s.Path)),
+	}
+	if s.Solver != "" {
+		attrs = append(attrs, slog.String("solver", s.Solver))
+	}
+	if s.Err != nil {
+		attrs = append(attrs, slog.String("error", s.Err.Error()))
+	} else {
+		attrs = append(attrs, slog.Float64("cutoff_cost", s.CutoffCost), slog.Float64("total_cost", s.TotalCost))
+	}
+	attrs = append(attrs, slog.Group("phases",
+		slog.Duration("validate", s.ValidateDuration),
+		slog.Duration("negative_scan", s.NegativeScanDuration),
+		slog.Duration("fill", s.FillDuration),
+		slog.Duration("output", s.OutputDuration),
+	))
+	return attrs
+}
@@ -0,0 +1,101 @@
+package slogobserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	optimization "github.com/greyskp/cost_optimization/optimizer"
+)
+
+// lockedBuffer lets the background goroutine write while the test reads after Close.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) entries(t *testing.T) []map[string]any {
+	t.Helper()
+	var res []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		res = append(res, entry)
+	}
+	return res
+}
+
+func TestLevelsAndSampling(t *testing.T) {
+	var out lockedBuffer
+	logger := slog.New(slog.NewJSONHandler(&out, nil))
+	o := New(logger, Options{SlowThreshold: time.Second, SampleEvery: 3})
+
+	for range 6 {
+		o.Observe(optimization.Stats{N: 10, Duration: time.Millisecond})
+	}
+	o.Observe(optimization.Stats{N: 10, Duration: 2 * time.Second})
+	o.Observe(optimization.Stats{Err: optimization.ErrEmptyInput})
+	o.Close()
+
+	entries := out.entries(t)
+	var levels []string
+	for _, e := range entries {
+		levels = append(levels, e["level"].(string))
+	}
+	if strings.Join(levels, ",") != "INFO,INFO,WARN,ERROR" {
+		t.Fatalf("got levels %v, expected INFO,INFO,WARN,ERROR", levels)
+	}
+	if entries[3]["error"] != optimization.ErrEmptyInput.Error() {
+		t.Fatalf("error attribute is %v", entries[3]["error"])
+	}
+	if entries[0]["n"] != float64(10) || entries[0]["msg"] != "cost optimization" {
+		t.Fatalf("unexpected first entry %v", entries[0])
+	}
+}
+
+func TestDisabledLevelAndDrops(t *testing.T) {
+	var out lockedBuffer
+	logger := slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	o := New(logger, Options{BufferSize: 1})
+
+	// Info records are filtered before queuing, errors always go through the bounded buffer.
+	o.Observe(optimization.Stats{N: 1})
+	for range 100 {
+		o.Observe(optimization.Stats{Err: optimization.ErrInvalidNumber})
+	}
+	o.Close()
+
+	logged := len(out.entries(t))
+	if uint64(logged)+o.Dropped() != 100 {
+		t.Fatalf("logged %d and dropped %d, expected 100 in total", logged, o.Dropped())
+	}
+}
+
+func TestWithOptimizer(t *testing.T) {
+	var out lockedBuffer
+	o := New(slog.New(slog.NewJSONHandler(&out, nil)), Options{})
+	if _, err := optimization.CostOptimization([]float64{3, -1, 2}, optimization.WithObserver(o)); err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	o.Close()
+
+	entries := out.entries(t)
+	if len(entries) != 1 || entries[0]["path"] != "heap" || entries[0]["total_cost"] != float64(1) {
+		t.Fatalf("unexpected entries %v", entries)
+	}
+}
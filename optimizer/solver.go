@@ -0,0 +1,197 @@
+package optimization
+
+import (
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// Solver picks the k smallest costs among the indices not yet selected in res (res[i] == 0),
+// lower indices first on equal costs, and marks them with 1. It is only called once the
+// negatives are marked and k > 0. The returned replacements are reported in Stats.
+type Solver interface {
+	Name() string
+	Select(prices []float64, res []int, k int) (replacements int)
+}
+
+// HeapSolver keeps the best candidates in a bounded max-heap: O(n log k). It is the default.
+type HeapSolver struct{}
+
+func (HeapSolver) Name() string { return "heap" }
+
+func (HeapSolver) Select(prices []float64, res []int, k int) int {
+	return fillSmallest(prices, res, k, nil)
+}
+
+func (HeapSolver) selectWithProgress(prices []float64, res []int, k int, progress func(done int)) int {
+	return fillSmallest(prices, res, k, progress)
+}
+
+// progressSolver is implemented by the built-in solvers whose single pass over the costs can
+// report how far it got while a lifecycle observer is listening.
+type progressSolver interface {
+	selectWithProgress(prices []float64, res []int, k int, progress func(done int)) int
+}
+
+// SortSolver sorts every candidate: O(n log n), mostly useful as a reference.
+type SortSolver struct{}
+
+func (SortSolver) Name() string { return "sort" }
+
+func (SortSolver) Select(prices []float64, res []int, k int) int {
+	candidates := collectCandidates(prices, res, 0, len(prices))
+	slices.SortFunc(candidates, compareCost)
+	markCandidates(res, candidates[:k])
+	return 0
+}
+
+// QuickselectSolver partitions the candidates around the k-th smallest: O(n) on average.
+type QuickselectSolver struct{}
+
+func (QuickselectSolver) Name() string { return "quickselect" }
+
+func (QuickselectSolver) Select(prices []float64, res []int, k int) int {
+	candidates := collectCandidates(prices, res, 0, len(prices))
+	selectSmallest(candidates, k)
+	markCandidates(res, candidates[:k])
+	return 0
+}
+
+// ParallelSolver runs quickselect on Workers chunks concurrently, then once more on the union of
+// the chunk winners. Workers defaults to GOMAXPROCS.
+type ParallelSolver struct {
+	Workers int
+}
+
+func (ParallelSolver) Name() string { return "parallel" }
+
+func (s ParallelSolver) Select(prices []float64, res []int, k int) int {
+	workers := s.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = max(min(workers, len(prices)), 1)
+
+	chunk := (len(prices) + workers - 1) / workers
+	winners := make([][]cost, workers)
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			candidates := collectCandidates(prices, res, min(w*chunk, len(prices)), min((w+1)*chunk, len(prices)))
+			if len(candidates) > k {
+				selectSmallest(candidates, k)
+				candidates = candidates[:k]
+			}
+			winners[w] = candidates
+		}()
+	}
+	wg.Wait()
+
+	candidates := slices.Concat(winners...)
+	selectSmallest(candidates, k)
+	markCandidates(res, candidates[:k])
+	return 0
+}
+
+// AutoSolver picks a solver per call from the input size and the share of negatives seen
+// during the scan. Stats.Solver records the solver it chose.
+type AutoSolver struct{}
+
+const autoParallelInput = 1 << 20
+const autoParallelWorkers = 4
+
+func (AutoSolver) Name() string { return "auto" }
+
+func (a AutoSolver) Select(prices []float64, res []int, k int) int {
+	return a.choose(len(prices), k).Select(prices, res, k)
+}
+
+func (AutoSolver) choose(n, k int) Solver {
+	negatives := requiredCount(n) - k
+	switch {
+	case k <= n/64 || negatives*4 >= n*3:
+		// Mostly negatives: few slots left, n log k is close to linear.
+		return HeapSolver{}
+	case n >= autoParallelInput && runtime.GOMAXPROCS(0) >= autoParallelWorkers:
+		return ParallelSolver{}
+	}
+	return QuickselectSolver{}
+}
+
+// solverByName returns the built-in solver with the given name.
+func solverByName(name string) (Solver, bool) {
+	for _, s := range []Solver{HeapSolver{}, SortSolver{}, QuickselectSolver{}, ParallelSolver{}, AutoSolver{}} {
+		if s.Name() == name {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// resolveSolver returns the solver that will actually run for n costs and k slots.
+func resolveSolver(s Solver, n, k int) Solver {
+	if a, ok := s.(AutoSolver); ok {
+		return a.choose(n, k)
+	}
+	return s
+}
+
+// collectCandidates returns the unselected costs of prices[from:to].
+func collectCandidates(prices []float64, res []int, from, to int) []cost {
+	candidates := make([]cost, 0, to-from)
+	for i := from; i < to; i++ {
+		if res[i] == 0 {
+			candidates = append(candidates, cost{prices[i], i})
+		}
+	}
+	return candidates
+}
+
+func markCandidates(res []int, candidates []cost) {
+	for _, c := range candidates {
+		res[c.index] = 1
+	}
+}
+
+// selectSmallest reorders c so that c[:k] holds its k smallest costs, in no particular order.
+func selectSmallest(c []cost, k int) {
+	lo, hi := 0, len(c)-1
+	for lo < hi {
+		p := partitionCosts(c, lo, hi)
+		switch {
+		case p == k:
+			return
+		case p < k:
+			lo = p + 1
+		default:
+			hi = p - 1
+		}
+	}
+}
+
+// partitionCosts partitions c[lo:hi+1] around a median-of-three pivot and returns its final position.
+func partitionCosts(c []cost, lo, hi int) int {
+	mid := lo + (hi-lo)/2
+	if compareCost(c[mid], c[lo]) < 0 {
+		c[mid], c[lo] = c[lo], c[mid]
+	}
+	if compareCost(c[hi], c[lo]) < 0 {
+		c[hi], c[lo] = c[lo], c[hi]
+	}
+	if compareCost(c[mid], c[hi]) < 0 {
+		c[mid], c[hi] = c[hi], c[mid]
+	}
+
+	pivot := c[hi]
+	i := lo
+	for j := lo; j < hi; j++ {
+		if compareCost(c[j], pivot) < 0 {
+			c[i], c[j] = c[j], c[i]
+			i++
+		}
+	}
+	c[i], c[hi] = c[hi], c[i]
+	return i
+}
@@ -0,0 +1,63 @@
+package optimization
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestSolversMatchReference(t *testing.T) {
+	inputs := [][]float64{
+		{3, 1, 2, 5},
+		{-10, 17, 15, -40, 20, 15, 17},
+		{5, 0, 5, math.Copysign(0, -1), 5, 0, math.Inf(1), 2},
+		randFloats(-100.0, 500.0, 1001),
+		randFloats(0.0, 3.0, 5000),
+	}
+	// Heavy ties exercise the index tie-break.
+	ties := randFloats(0.0, 4.0, 3000)
+	for i := range ties {
+		ties[i] = math.Floor(ties[i])
+	}
+	inputs = append(inputs, ties)
+
+	solvers := []Solver{HeapSolver{}, SortSolver{}, QuickselectSolver{}, ParallelSolver{}, ParallelSolver{Workers: 7}, AutoSolver{}}
+	for _, costs := range inputs {
+		expected := referenceSelection(costs)
+		for _, solver := range solvers {
+			result, err := CostOptimization(costs, WithSolver(solver))
+			if err != nil {
+				t.Fatalf("%s: CostOptimization returned unexpected error: %v", solver.Name(), err)
+			}
+			if !slices.Equal(result, expected) {
+				t.Fatalf("%s: CostOptimization(%v) got %v, expected %v", solver.Name(), costs, result, expected)
+			}
+		}
+	}
+}
+
+func TestSolverStats(t *testing.T) {
+	costs := randFloats(0.0, 500.0, 10000)
+
+	var obs statsRecorder
+	if _, err := CostOptimization(costs, WithObserver(&obs)); err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	if obs.last.Solver != "heap" || obs.last.Path != PathHeap {
+		t.Fatalf("default run reported solver %q and path %q, expected heap", obs.last.Solver, obs.last.Path)
+	}
+
+	if _, err := CostOptimization(costs, WithSolver(AutoSolver{}), WithObserver(&obs)); err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	if obs.last.Solver != "quickselect" {
+		t.Fatalf("auto run reported solver %q, expected quickselect", obs.last.Solver)
+	}
+
+	if _, err := CostOptimization([]float64{-1, -2, 3}, WithSolver(SortSolver{}), WithObserver(&obs)); err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	if obs.last.Solver != "" {
+		t.Fatalf("negatives run reported solver %q, expected none", obs.last.Solver)
+	}
+}
@@ -0,0 +1,81 @@
+package optimization
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidPenalty = errors.New("switching penalty must be a finite non-negative number")
+var ErrInvalidSelection = errors.New("selection must contain only 0 and 1")
+
+// WithPreviousSelection makes CostOptimization minimize the total cost plus penalty for every item
+// whose flag differs from prev, still selecting at least ⌈n/2⌉ items. prev must have the same
+// length as the costs. Stats.Switches and Stats.SwitchesAvoided report the effect against a plain run.
+//
+// Since |x-p| = x(1-2p) + p for binary x and p, this is the plain problem over the adjusted costs
+// c+penalty (for items not selected before) and c-penalty (for items selected before), so the
+// result is exact.
+func WithPreviousSelection(prev []int, penalty float64) Option {
+	return func(opt *options) {
+		opt.previous = prev
+		opt.penalty = penalty
+	}
+}
+
+// stableCosts validates the previous selection and returns the penalty-adjusted costs.
+func stableCosts(prices []float64, prev []int, penalty float64) ([]float64, error) {
+	if len(prev) != len(prices) {
+		return nil, ErrDifferentSizes
+	}
+	if math.IsNaN(penalty) || math.IsInf(penalty, 0) || penalty < 0 {
+		return nil, ErrInvalidPenalty
+	}
+
+	adjusted := make([]float64, len(prices))
+	for i, value := range prices {
+		switch prev[i] {
+		case 0:
+			adjusted[i] = value + penalty
+		case 1:
+			adjusted[i] = value - penalty
+		default:
+			return nil, ErrInvalidSelection
+		}
+	}
+	return adjusted, nil
+}
+
+// countSwitches returns the number of items whose flag differs between a and b.
+func countSwitches(a, b []int) int {
+	switches := 0
+	for i := range a {
+		if a[i] != b[i] {
+			switches++
+		}
+	}
+	return switches
+}
+
+// formatSelection and parseSelection encode a selection as a string of 0 and 1 for describe.
+func formatSelection(sel []int) string {
+	var b strings.Builder
+	b.Grow(len(sel))
+	for _, v := range sel {
+		b.WriteString(strconv.Itoa(v))
+	}
+	return b.String()
+}
+
+func parseSelection(s string) ([]int, error) {
+	sel := make([]int, len(s))
+	for i, c := range s {
+		if c != '0' && c != '1' {
+			return nil, fmt.Errorf("%w: previous selection %s", ErrInvalidFormat, strconv.Quote(s))
+		}
+		sel[i] = int(c - '0')
+	}
+	return sel, nil
+}
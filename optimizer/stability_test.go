@@ -0,0 +1,122 @@
+package optimization
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestPreviousSelection(t *testing.T) {
+	costs := []float64{5, 1, 4, 2, 3, 6}
+	prev := []int{1, 0, 1, 0, 1, 0} // 5, 4, 3 selected yesterday
+	stats := &statsRecorder{}
+
+	out, err := CostOptimization(costs, WithPreviousSelection(prev, 1), WithObserver(stats))
+	if err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	// Adjusted costs 4 2 3 3 2 7: keep 4 and 3, and 1 replaces 5.
+	if expected := []int{0, 1, 1, 0, 1, 0}; !slices.Equal(out, expected) {
+		t.Fatalf("output %v, expected %v", out, expected)
+	}
+	// The plain run picks 1, 2, 3 and switches four items.
+	if stats.last.Switches != 2 || stats.last.SwitchesAvoided != 2 {
+		t.Fatalf("switches %d, avoided %d, expected 2 and 2", stats.last.Switches, stats.last.SwitchesAvoided)
+	}
+	if stats.last.TotalCost != 8 {
+		t.Fatalf("total cost %v, expected 8 on the original costs", stats.last.TotalCost)
+	}
+
+	// A large enough penalty keeps yesterday's selection.
+	out, _ = CostOptimization(costs, WithPreviousSelection(prev, 2))
+	if !slices.Equal(out, prev) {
+		t.Fatalf("penalty 2 output %v, expected %v", out, prev)
+	}
+
+	// Without a penalty the plain selection comes back.
+	out, _ = CostOptimization(costs, WithPreviousSelection(prev, 0))
+	if plain, _ := CostOptimization(costs); !slices.Equal(out, plain) {
+		t.Fatalf("zero penalty output %v, expected %v", out, plain)
+	}
+}
+
+func TestPreviousSelectionIsOptimal(t *testing.T) {
+	r := rand.New(rand.NewPCG(7, 46))
+	for range 300 {
+		n := 1 + r.IntN(10)
+		costs := make([]float64, n)
+		prev := make([]int, n)
+		for i := range costs {
+			costs[i] = float64(r.IntN(21) - 8)
+			prev[i] = r.IntN(2)
+		}
+		penalty := float64(r.IntN(6))
+
+		out, err := CostOptimization(costs, WithPreviousSelection(prev, penalty))
+		if err != nil {
+			t.Fatalf("CostOptimization returned unexpected error: %v", err)
+		}
+		if countOnes(out) < requiredCount(n) {
+			t.Fatalf("selected %d of %d", countOnes(out), n)
+		}
+		objective := func(sel []int) float64 {
+			total, _ := TotalCost(costs, sel)
+			return total + penalty*float64(countSwitches(prev, sel))
+		}
+		if got, best := objective(out), bruteForceStable(n, objective); got != best {
+			t.Fatalf("costs %v prev %v penalty %v: objective %v, optimum %v", costs, prev, penalty, got, best)
+		}
+	}
+}
+
+func TestPreviousSelectionErrors(t *testing.T) {
+	costs := []float64{1, 2, 3}
+	cases := []struct {
+		prev    []int
+		penalty float64
+		err     error
+	}{
+		{[]int{1, 0}, 1, ErrDifferentSizes},
+		{[]int{1, 0, 2}, 1, ErrInvalidSelection},
+		{[]int{1, 0, 1}, -1, ErrInvalidPenalty},
+		{[]int{1, 0, 1}, math.NaN(), ErrInvalidPenalty},
+		{[]int{1, 0, 1}, math.Inf(1), ErrInvalidPenalty},
+	}
+	for _, c := range cases {
+		if _, err := CostOptimization(costs, WithPreviousSelection(c.prev, c.penalty)); !errors.Is(err, c.err) {
+			t.Errorf("prev %v penalty %v: expected %v, got %v", c.prev, c.penalty, c.err, err)
+		}
+	}
+}
+
+func TestPreviousSelectionAudit(t *testing.T) {
+	costs := []float64{5, 1, 4, 2, 3, 6}
+	var rec AuditRecord
+	out, err := CostOptimization(costs, WithPreviousSelection([]int{1, 0, 1, 0, 1, 0}, 2), WithAudit(&rec, auditKey))
+	if err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	if rec.Options["previous_selection"] != "101010" || rec.Options["switch_penalty"] != "2" {
+		t.Fatalf("options not described: %v", rec.Options)
+	}
+	if err := VerifyAudit(rec, auditKey, costs, out); err != nil {
+		t.Fatalf("VerifyAudit rejected a stability-aware decision: %v", err)
+	}
+}
+
+// bruteForceStable returns the smallest objective over every selection of at least ⌈n/2⌉ items.
+func bruteForceStable(n int, objective func([]int) float64) float64 {
+	best := math.Inf(1)
+	sel := make([]int, n)
+	for mask := range 1 << n {
+		for i := range sel {
+			sel[i] = mask >> i & 1
+		}
+		if countOnes(sel) >= requiredCount(n) {
+			best = min(best, objective(sel))
+		}
+	}
+	return best
+}
@@ -0,0 +1,237 @@
+package optimization
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+)
+
+var ErrInvalidFormat = errors.New("malformed cost stream")
+
+// StreamFormat describes how costs are encoded on the input and how the selection mask is written.
+type StreamFormat int
+
+const (
+	// FormatText reads whitespace separated decimal costs and writes one "0" or "1" line per cost.
+	FormatText StreamFormat = iota
+	// FormatBinary reads little-endian float64 costs and writes one 0 or 1 byte per cost.
+	FormatBinary
+)
+
+// costSource yields every cost of a stream in order. It can be replayed as many times as needed.
+type costSource interface {
+	each(fn func(index int, value float64)) error
+}
+
+// CostOptimizationStream applies the same selection rule as CostOptimization to costs read from r
+// and writes the selection mask to w, without holding the costs or the mask in memory.
+// Binary input that implements io.Seeker is read several times in place; any other input is first
+// spilled to a temporary binary file (see WithTempDir). The k smallest non-negative costs are found
+// with fixed-size counting passes, so memory usage does not depend on the input size.
+func CostOptimizationStream(r io.Reader, w io.Writer, format StreamFormat, opts ...Option) (err error) {
+	cfg := applyOptions(opts)
+	// The number of costs is only known once they have been read.
+	track := cfg.beginCall("optimization.CostOptimizationStream", -1)
+	var stats Stats
+	defer track.finish(&stats, &err)
+
+	if err = cfg.supports(0); err != nil {
+		return err
+	}
+
+	if format != FormatText && format != FormatBinary {
+		return fmt.Errorf("%w: unknown format %d", ErrInvalidFormat, format)
+	}
+
+	src, cleanup, err := openSource(r, format, cfg.tempDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// First pass: validate and count the negatives, which are always selected.
+	err = src.each(func(_ int, value float64) {
+		stats.N++
+		if stats.N%progressInterval == 0 {
+			track.progress(PhaseValidate, stats.N, -1)
+		}
+		if value < 0 {
+			stats.Negatives++
+		}
+	})
+	stats.ValidateDuration = track.lap(PhaseValidate)
+	if err != nil {
+		return err
+	}
+	if stats.N == 0 {
+		return ErrEmptyInput
+	}
+
+	stats.LeftToFill = max(requiredCount(stats.N)-stats.Negatives, 0)
+	stats.SelectedCount = stats.Negatives
+	stats.Path = PathNegatives
+
+	// Locate the key of the last non-negative cost that has to be selected, and how many
+	// costs sharing that key are needed (lower indices first).
+	var cutoff uint64
+	ties := 0
+	if stats.LeftToFill > 0 {
+		stats.Path = PathRadix
+		var below int
+		cutoff, below, err = radixSelect(stats.LeftToFill, func(visit func(uint64)) error {
+			return src.each(func(_ int, value float64) {
+				if value >= 0 {
+					visit(floatKey(value))
+				}
+			})
+		})
+		if err != nil {
+			return err
+		}
+		ties = stats.LeftToFill - below
+	}
+	stats.FillDuration = track.lap(PhaseFill)
+
+	out := bufio.NewWriter(w)
+	var werr error
+	err = src.each(func(_ int, value float64) {
+		selected := value < 0
+		if !selected && stats.LeftToFill > 0 {
+			key := floatKey(value)
+			if key < cutoff {
+				selected = true
+			} else if key == cutoff && ties > 0 {
+				selected = true
+				ties--
+			}
+			if selected {
+				stats.SelectedCount++
+			}
+		}
+		if werr == nil {
+			werr = writeFlag(out, format, selected)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if werr == nil {
+		werr = out.Flush()
+	}
+	stats.OutputDuration = track.lap(PhaseOutput)
+	return werr
+}
+
+// openSource prepares a replayable costSource for r. The returned cleanup must always be called.
+func openSource(r io.Reader, format StreamFormat, tempDir string) (costSource, func(), error) {
+	if seeker, ok := r.(io.ReadSeeker); ok && format == FormatBinary {
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err == nil {
+			return &seekSource{r: seeker, offset: offset}, func() {}, nil
+		}
+	}
+
+	file, err := os.CreateTemp(tempDir, "costopt-*.bin")
+	if err != nil {
+		return nil, func() {}, err
+	}
+	cleanup := func() {
+		file.Close()
+		os.Remove(file.Name())
+	}
+
+	spill := bufio.NewWriter(file)
+	var buf [8]byte
+	err = readCosts(r, format, func(_ int, value float64) {
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(value))
+		spill.Write(buf[:])
+	})
+	if err == nil {
+		err = spill.Flush()
+	}
+	if err != nil {
+		cleanup()
+		return nil, func() {}, err
+	}
+
+	return &seekSource{r: file}, cleanup, nil
+}
+
+// seekSource replays binary costs by rewinding a seekable reader to where they start.
+type seekSource struct {
+	r      io.ReadSeeker
+	offset int64
+}
+
+func (s *seekSource) each(fn func(index int, value float64)) error {
+	if _, err := s.r.Seek(s.offset, io.SeekStart); err != nil {
+		return err
+	}
+	return readCosts(s.r, FormatBinary, fn)
+}
+
+// readCosts decodes every cost of r, rejecting NaN values.
+func readCosts(r io.Reader, format StreamFormat, fn func(index int, value float64)) error {
+	index := 0
+	emit := func(value float64) error {
+		if math.IsNaN(value) {
+			return ErrInvalidNumber
+		}
+		fn(index, value)
+		index++
+		return nil
+	}
+
+	if format == FormatBinary {
+		in := bufio.NewReader(r)
+		var buf [8]byte
+		for {
+			_, err := io.ReadFull(in, buf[:])
+			if err == io.EOF {
+				return nil
+			}
+			if err == io.ErrUnexpectedEOF {
+				return fmt.Errorf("%w: truncated value at index %d", ErrInvalidFormat, index)
+			}
+			if err != nil {
+				return err
+			}
+			if err := emit(math.Float64frombits(binary.LittleEndian.Uint64(buf[:]))); err != nil {
+				return err
+			}
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+	for scanner.Scan() {
+		value, err := strconv.ParseFloat(scanner.Text(), 64)
+		if err != nil && !errors.Is(err, strconv.ErrRange) {
+			return fmt.Errorf("%w: %q at index %d", ErrInvalidFormat, scanner.Text(), index)
+		}
+		if err := emit(value); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func writeFlag(w *bufio.Writer, format StreamFormat, selected bool) error {
+	if format == FormatBinary {
+		if selected {
+			return w.WriteByte(1)
+		}
+		return w.WriteByte(0)
+	}
+	if selected {
+		_, err := w.WriteString("1\n")
+		return err
+	}
+	_, err := w.WriteString("0\n")
+	return err
+}
@@ -0,0 +1,104 @@
+package optimization
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// readerOnly hides any Seek method so the spill path is exercised.
+type readerOnly struct{ io.Reader }
+
+func TestStreamMatchesCostOptimization(t *testing.T) {
+	inputs := [][]float64{
+		{-10, 17, 15, -40, 20},
+		{0.0, 0, 0.0, 0, 0},
+		{math.Inf(-1), 3, math.Inf(1)},
+		{5, 5, 5, 1, 1, 1, 5, 5},
+		{0, math.Copysign(0, -1), 0, 2},
+		randFloats(-100.0, 500.0, 1001),
+		randFloats(0.0, 500.0, 2000),
+		randFloats(-500.0, -1.0, 300),
+	}
+
+	for _, costs := range inputs {
+		expected, err := CostOptimization(costs)
+		if err != nil {
+			t.Fatalf("CostOptimization returned unexpected error: %v", err)
+		}
+
+		var text strings.Builder
+		for _, c := range costs {
+			text.WriteString(strconv.FormatFloat(c, 'g', -1, 64) + " ")
+		}
+		var bin bytes.Buffer
+		binary.Write(&bin, binary.LittleEndian, costs)
+
+		cases := []struct {
+			name   string
+			r      io.Reader
+			format StreamFormat
+		}{
+			{"text", strings.NewReader(text.String()), FormatText},
+			{"binary seeker", bytes.NewReader(bin.Bytes()), FormatBinary},
+			{"binary spill", readerOnly{bytes.NewReader(bin.Bytes())}, FormatBinary},
+		}
+
+		for _, c := range cases {
+			var out bytes.Buffer
+			if err := CostOptimizationStream(c.r, &out, c.format, WithTempDir(t.TempDir())); err != nil {
+				t.Fatalf("%s: CostOptimizationStream returned unexpected error: %v", c.name, err)
+			}
+			got := decodeMask(t, out.Bytes(), c.format)
+			if len(got) != len(expected) {
+				t.Fatalf("%s: got %d flags, expected %d", c.name, len(got), len(expected))
+			}
+			for i := range expected {
+				if got[i] != expected[i] {
+					t.Fatalf("%s: index %d (cost %v) got %d, expected %d", c.name, i, costs[i], got[i], expected[i])
+				}
+			}
+		}
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	var out bytes.Buffer
+
+	if err := CostOptimizationStream(strings.NewReader("  \n"), &out, FormatText); err != ErrEmptyInput {
+		t.Fatalf("empty stream got %v, expected %v", err, ErrEmptyInput)
+	}
+	if err := CostOptimizationStream(strings.NewReader("1 NaN 2"), &out, FormatText); err != ErrInvalidNumber {
+		t.Fatalf("NaN stream got %v, expected %v", err, ErrInvalidNumber)
+	}
+	if err := CostOptimizationStream(strings.NewReader("1 abc 2"), &out, FormatText); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("malformed stream got %v, expected %v", err, ErrInvalidFormat)
+	}
+	if err := CostOptimizationStream(bytes.NewReader(make([]byte, 12)), &out, FormatBinary); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("truncated stream got %v, expected %v", err, ErrInvalidFormat)
+	}
+}
+
+func decodeMask(t *testing.T, data []byte, format StreamFormat) []int {
+	t.Helper()
+	var res []int
+	if format == FormatBinary {
+		for _, b := range data {
+			res = append(res, int(b))
+		}
+		return res
+	}
+	for _, line := range strings.Fields(string(data)) {
+		v, err := strconv.Atoi(line)
+		if err != nil {
+			t.Fatalf("invalid mask line %q", line)
+		}
+		res = append(res, v)
+	}
+	return res
+}
@@ -0,0 +1,308 @@
+package optimization
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+var ErrSummaryMismatch = errors.New("summaries describe different inputs")
+var ErrIncompleteSummary = errors.New("summary does not cover the whole input")
+
+const summaryMagic = "COSM"
+const summaryVersion = 2
+
+// Cutoff is the compact form of a selection: every negative cost is selected, plus every
+// non-negative cost ordered at or before (Price, Index), lower indices winning ties.
+type Cutoff struct {
+	Price float64
+	Index int
+	Fill  int // number of non-negative costs selected, 0 when the negatives are enough
+}
+
+// Selected reports whether the cost at the given global index belongs to the selection.
+func (c Cutoff) Selected(price float64, index int) bool {
+	if price < 0 {
+		return true
+	}
+	if c.Fill == 0 {
+		return false
+	}
+	return price < c.Price || (price == c.Price && index <= c.Index)
+}
+
+// Mask returns the selection for a shard whose first element sits at the given global offset.
+// Concatenating the masks of every shard gives the output of CostOptimization on the full input.
+func (c Cutoff) Mask(costs []float64, offset int) []int {
+	res := make([]int, len(costs))
+	for i, value := range costs {
+		if c.Selected(value, offset+i) {
+			res[i] = 1
+		}
+	}
+	return res
+}
+
+// Summary is a mergeable digest of one shard of a larger input. It keeps the counts and at most
+// ⌈total/2⌉ of the smallest non-negative costs, which is enough to find the exact global cutoff.
+// Shards must be disjoint, which Merge checks; Merge is associative and commutative.
+type Summary struct {
+	total      int
+	n          int
+	negatives  int
+	ranges     []indexRange // covered indices, sorted, neither overlapping nor adjacent
+	candidates []cost       // sorted by (price, index)
+}
+
+// indexRange is the half-open range of indices [start, end).
+type indexRange struct {
+	start, end int
+}
+
+// Summarize builds the summary of costs, which occupy indices [offset, offset+len(costs)) of an
+// input of total elements.
+func Summarize(costs []float64, offset, total int) (*Summary, error) {
+	if offset < 0 || offset+len(costs) > total {
+		return nil, ErrSummaryMismatch
+	}
+
+	s := &Summary{total: total, n: len(costs)}
+	if len(costs) > 0 {
+		s.ranges = []indexRange{{offset, offset + len(costs)}}
+	}
+	// A shard never keeps more candidates than it has costs, however large the whole input is.
+	limit := min(requiredCount(total), len(costs))
+
+	smallest := NewBoundedTopK(limit, lessCost)
+	for i, value := range costs {
+		if math.IsNaN(value) {
+			return nil, ErrInvalidNumber
+		}
+		if value < 0 {
+			s.negatives++
+			continue
+		}
+		smallest.Offer(cost{value, offset + i})
+	}
+
+	s.candidates = smallest.Items()
+	slices.SortFunc(s.candidates, compareCost)
+	return s, nil
+}
+
+// N returns the number of costs covered by the summary.
+func (s *Summary) N() int { return s.n }
+
+// Negatives returns the number of negative costs covered by the summary.
+func (s *Summary) Negatives() int { return s.negatives }
+
+// Merge folds other into s. Summaries of the same input whose shards overlap are rejected.
+func (s *Summary) Merge(other *Summary) error {
+	if s.total != other.total || s.n+other.n > s.total {
+		return ErrSummaryMismatch
+	}
+	ranges, ok := unionRanges(s.ranges, other.ranges)
+	if !ok {
+		return fmt.Errorf("%w: shards overlap", ErrSummaryMismatch)
+	}
+
+	limit := requiredCount(s.total)
+	merged := make([]cost, 0, min(len(s.candidates)+len(other.candidates), limit))
+	a, b := s.candidates, other.candidates
+	for len(merged) < limit && (len(a) > 0 || len(b) > 0) {
+		if len(b) == 0 || (len(a) > 0 && compareCost(a[0], b[0]) <= 0) {
+			merged = append(merged, a[0])
+			a = a[1:]
+		} else {
+			merged = append(merged, b[0])
+			b = b[1:]
+		}
+	}
+
+	s.n += other.n
+	s.negatives += other.negatives
+	s.ranges = ranges
+	s.candidates = merged
+	return nil
+}
+
+// unionRanges merges two sorted range lists, coalescing adjacent ranges. It reports false when
+// they overlap.
+func unionRanges(a, b []indexRange) ([]indexRange, bool) {
+	union := make([]indexRange, 0, len(a)+len(b))
+	for len(a) > 0 || len(b) > 0 {
+		var next indexRange
+		if len(b) == 0 || (len(a) > 0 && a[0].start < b[0].start) {
+			next, a = a[0], a[1:]
+		} else {
+			next, b = b[0], b[1:]
+		}
+		if k := len(union) - 1; k >= 0 {
+			if next.start < union[k].end {
+				return nil, false
+			}
+			if next.start == union[k].end {
+				union[k].end = next.end
+				continue
+			}
+		}
+		union = append(union, next)
+	}
+	return union, true
+}
+
+// candidateCount is the number of candidates a summary of n costs, negatives of them negative,
+// keeps out of an input of total costs.
+func candidateCount(total, n, negatives int) int {
+	return min(n-negatives, requiredCount(total))
+}
+
+// Finalize returns the global cutoff once the summary covers the whole input.
+func (s *Summary) Finalize() (Cutoff, error) {
+	if s.total == 0 {
+		return Cutoff{}, ErrEmptyInput
+	}
+	if s.n != s.total {
+		return Cutoff{}, ErrIncompleteSummary
+	}
+
+	fill := requiredCount(s.total) - s.negatives
+	if fill <= 0 {
+		return Cutoff{}, nil
+	}
+	if fill > len(s.candidates) {
+		return Cutoff{}, fmt.Errorf("%w: summary holds %d candidates, %d needed", ErrInvalidFormat, len(s.candidates), fill)
+	}
+	last := s.candidates[fill-1]
+	return Cutoff{Price: last.price, Index: last.index, Fill: fill}, nil
+}
+
+// MarshalBinary encodes the summary so it can be shipped to the process doing the merge.
+func (s *Summary) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, len(summaryMagic)+1+4*binary.MaxVarintLen64+len(s.candidates)*(8+binary.MaxVarintLen64))
+	buf = append(buf, summaryMagic...)
+	buf = append(buf, summaryVersion)
+	buf = binary.AppendUvarint(buf, uint64(s.total))
+	buf = binary.AppendUvarint(buf, uint64(s.n))
+	buf = binary.AppendUvarint(buf, uint64(s.negatives))
+	buf = binary.AppendUvarint(buf, uint64(len(s.ranges)))
+	for _, r := range s.ranges {
+		buf = binary.AppendUvarint(buf, uint64(r.start))
+		buf = binary.AppendUvarint(buf, uint64(r.end))
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(s.candidates)))
+	for _, c := range s.candidates {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(c.price))
+		buf = binary.AppendUvarint(buf, uint64(c.index))
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a summary produced by MarshalBinary.
+func (s *Summary) UnmarshalBinary(data []byte) error {
+	malformed := func(reason string) error {
+		return fmt.Errorf("%w: summary %s", ErrInvalidFormat, reason)
+	}
+
+	if len(data) < len(summaryMagic)+1 || string(data[:len(summaryMagic)]) != summaryMagic {
+		return malformed("header missing")
+	}
+	if data[len(summaryMagic)] != summaryVersion {
+		return malformed("version unsupported")
+	}
+	data = data[len(summaryMagic)+1:]
+
+	readUvarint := func() (int, bool) {
+		v, size := binary.Uvarint(data)
+		if size <= 0 || v > math.MaxInt {
+			return 0, false
+		}
+		data = data[size:]
+		return int(v), true
+	}
+
+	var fields [4]int
+	for i := range fields {
+		v, ok := readUvarint()
+		if !ok {
+			return malformed("header truncated")
+		}
+		fields[i] = v
+	}
+	total, n, negatives, rangeCount := fields[0], fields[1], fields[2], fields[3]
+	if n > total || negatives > n || rangeCount > n {
+		return malformed("counts inconsistent")
+	}
+
+	// Counts are checked against the bytes left before anything is allocated for them: a range
+	// takes at least two bytes and a candidate at least nine.
+	if rangeCount > len(data)/2 {
+		return malformed("ranges truncated")
+	}
+	ranges := make([]indexRange, 0, rangeCount)
+	covered := 0
+	for range rangeCount {
+		start, ok1 := readUvarint()
+		end, ok2 := readUvarint()
+		if !ok1 || !ok2 {
+			return malformed("ranges truncated")
+		}
+		if start >= end || end > total || (len(ranges) > 0 && start <= ranges[len(ranges)-1].end) {
+			return malformed("ranges inconsistent")
+		}
+		ranges = append(ranges, indexRange{start, end})
+		covered += end - start
+	}
+	if covered != n {
+		return malformed("ranges do not match the count")
+	}
+
+	count, ok := readUvarint()
+	if !ok {
+		return malformed("header truncated")
+	}
+	if count != candidateCount(total, n, negatives) {
+		return malformed("counts inconsistent")
+	}
+
+	if count > len(data)/9 {
+		return malformed("candidates truncated")
+	}
+	candidates := make([]cost, 0, count)
+	for range count {
+		if len(data) < 8 {
+			return malformed("candidates truncated")
+		}
+		price := math.Float64frombits(binary.LittleEndian.Uint64(data))
+		data = data[8:]
+		index, ok := readUvarint()
+		if !ok {
+			return malformed("candidates truncated")
+		}
+		c := cost{price, index}
+		if math.IsNaN(price) || price < 0 || !coveredIndex(ranges, index) {
+			return malformed("candidate out of range")
+		}
+		if len(candidates) > 0 && compareCost(candidates[len(candidates)-1], c) >= 0 {
+			return malformed("candidates not sorted")
+		}
+		candidates = append(candidates, c)
+	}
+	if len(data) != 0 {
+		return malformed("trailing data")
+	}
+
+	*s = Summary{total: total, n: n, negatives: negatives, ranges: ranges, candidates: candidates}
+	return nil
+}
+
+// coveredIndex reports whether index lies in one of the sorted ranges.
+func coveredIndex(ranges []indexRange, index int) bool {
+	k, _ := slices.BinarySearchFunc(ranges, index, func(r indexRange, index int) int {
+		return cmp.Compare(r.end, index+1)
+	})
+	return k < len(ranges) && ranges[k].start <= index
+}
@@ -0,0 +1,164 @@
+package optimization
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestSummaryMatchesCostOptimization(t *testing.T) {
+	inputs := [][]float64{
+		{-10, 17, 15, -40, 20},
+		{0.0, 0, 0.0, 0, 0},
+		{1, 2, 3, 4, 100, 100, 100, 100},
+		randFloats(-100.0, 500.0, 999),
+		randFloats(0.0, 5.0, 400),
+		randFloats(-500.0, -1.0, 50),
+	}
+
+	for _, costs := range inputs {
+		expected, err := CostOptimization(costs)
+		if err != nil {
+			t.Fatalf("CostOptimization returned unexpected error: %v", err)
+		}
+
+		// Split at random points and merge the shards in reverse, shipping each one through the binary encoding.
+		bounds := []int{0}
+		for bounds[len(bounds)-1] < len(costs) {
+			bounds = append(bounds, min(bounds[len(bounds)-1]+1+rand.IntN(len(costs)), len(costs)))
+		}
+
+		var merged *Summary
+		for i := len(bounds) - 1; i > 0; i-- {
+			shard, err := Summarize(costs[bounds[i-1]:bounds[i]], bounds[i-1], len(costs))
+			if err != nil {
+				t.Fatalf("Summarize returned unexpected error: %v", err)
+			}
+			data, err := shard.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary returned unexpected error: %v", err)
+			}
+			decoded := &Summary{}
+			if err := decoded.UnmarshalBinary(data); err != nil {
+				t.Fatalf("UnmarshalBinary returned unexpected error: %v", err)
+			}
+			if merged == nil {
+				merged = decoded
+			} else if err := decoded.Merge(merged); err != nil {
+				t.Fatalf("Merge returned unexpected error: %v", err)
+			} else {
+				merged = decoded
+			}
+		}
+
+		cutoff, err := merged.Finalize()
+		if err != nil {
+			t.Fatalf("Finalize returned unexpected error: %v", err)
+		}
+
+		var result []int
+		for i := 1; i < len(bounds); i++ {
+			result = append(result, cutoff.Mask(costs[bounds[i-1]:bounds[i]], bounds[i-1])...)
+		}
+		for i := range expected {
+			if result[i] != expected[i] {
+				t.Fatalf("index %d (cost %v) got %d, expected %d", i, costs[i], result[i], expected[i])
+			}
+		}
+	}
+}
+
+func TestSummaryErrors(t *testing.T) {
+	a, _ := Summarize([]float64{1, 2}, 0, 4)
+
+	if _, err := a.Finalize(); err != ErrIncompleteSummary {
+		t.Fatalf("partial summary got %v, expected %v", err, ErrIncompleteSummary)
+	}
+
+	other, _ := Summarize([]float64{1, 2}, 2, 5)
+	if err := a.Merge(other); err != ErrSummaryMismatch {
+		t.Fatalf("mismatched merge got %v, expected %v", err, ErrSummaryMismatch)
+	}
+
+	if _, err := Summarize([]float64{1, 2}, 3, 4); err != ErrSummaryMismatch {
+		t.Fatalf("out of range shard got %v, expected %v", err, ErrSummaryMismatch)
+	}
+
+	if err := (&Summary{}).UnmarshalBinary([]byte("COSM\x02\x05")); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("truncated summary got %v, expected %v", err, ErrInvalidFormat)
+	}
+
+	// Counts of 2^62 that no payload follows must be rejected before anything is allocated for them.
+	huge := binary.AppendUvarint(nil, 1<<62)
+	header := append([]byte("COSM\x02"), huge...)
+	header = append(header, huge...)
+	header = append(header, 0)
+	if err := (&Summary{}).UnmarshalBinary(append(header, huge...)); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("summary with a huge range count got %v, expected %v", err, ErrInvalidFormat)
+	}
+	// One range covering all 2^62 indices, then the 2^61 candidates that many costs call for.
+	ranged := append(append(append(header[:len(header):len(header)], 1, 0), huge...), binary.AppendUvarint(nil, 1<<61)...)
+	if err := (&Summary{}).UnmarshalBinary(ranged); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("summary with a huge candidate count got %v, expected %v", err, ErrInvalidFormat)
+	}
+
+	// Two non-negative costs out of two, but no candidate kept to find the cutoff with.
+	if err := (&Summary{}).UnmarshalBinary([]byte("COSM\x02\x02\x02\x00\x01\x00\x02\x00")); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("summary without candidates got %v, expected %v", err, ErrInvalidFormat)
+	}
+	if _, err := (&Summary{total: 2, n: 2}).Finalize(); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("Finalize without candidates got %v, expected %v", err, ErrInvalidFormat)
+	}
+}
+
+func TestSummaryLargeTotal(t *testing.T) {
+	// A small shard of a huge input keeps no more candidates than it has costs.
+	shard, err := Summarize([]float64{3, -1, 2}, 0, 100_000_000)
+	if err != nil {
+		t.Fatalf("Summarize returned unexpected error: %v", err)
+	}
+	if got := cap(shard.candidates); got > 3 {
+		t.Fatalf("shard reserved %d candidates, expected at most 3", got)
+	}
+
+	data, err := shard.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary returned unexpected error: %v", err)
+	}
+	decoded := &Summary{}
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary returned unexpected error: %v", err)
+	}
+	if decoded.N() != 3 || decoded.Negatives() != 1 || len(decoded.candidates) != 2 {
+		t.Fatalf("decoded summary %+v, expected 3 costs, 1 negative and 2 candidates", decoded)
+	}
+}
+
+func TestSummaryOverlap(t *testing.T) {
+	costs := []float64{1, 2, 3, 4}
+	a, _ := Summarize(costs[:2], 0, 4)
+	if err := a.Merge(a); !errors.Is(err, ErrSummaryMismatch) {
+		t.Fatalf("self merge got %v, expected %v", err, ErrSummaryMismatch)
+	}
+
+	overlapping, _ := Summarize(costs[1:3], 1, 4)
+	if err := a.Merge(overlapping); !errors.Is(err, ErrSummaryMismatch) {
+		t.Fatalf("overlapping merge got %v, expected %v", err, ErrSummaryMismatch)
+	}
+
+	// The failed merges leave a untouched; the disjoint rest still completes it.
+	rest, _ := Summarize(costs[2:], 2, 4)
+	data, _ := rest.MarshalBinary()
+	decoded := &Summary{}
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary returned unexpected error: %v", err)
+	}
+	if err := a.Merge(decoded); err != nil {
+		t.Fatalf("Merge returned unexpected error: %v", err)
+	}
+	cutoff, err := a.Finalize()
+	if err != nil || cutoff != (Cutoff{Price: 2, Index: 1, Fill: 2}) {
+		t.Fatalf("cutoff %+v (err %v), expected {2 1 2}", cutoff, err)
+	}
+}
@@ -0,0 +1,120 @@
+package optimization
+
+// BoundedTopK keeps the k smallest items offered to it, ordered by less. Items that compare equal
+// are resolved deterministically: the ones offered first are kept. The storage is allocated once
+// by NewBoundedTopK, so Offer and Reset never allocate.
+type BoundedTopK[T any] struct {
+	k     int
+	less  func(a, b T) bool
+	items []T      // max-heap: items[0] is the first to be evicted
+	seqs  []uint64 // offer order of items, used to break ties
+	next  uint64
+}
+
+// NewBoundedTopK returns an empty container keeping at most k items.
+func NewBoundedTopK[T any](k int, less func(a, b T) bool) *BoundedTopK[T] {
+	k = max(k, 0)
+	return &BoundedTopK[T]{
+		k:     k,
+		less:  less,
+		items: make([]T, 0, k),
+		seqs:  make([]uint64, 0, k),
+	}
+}
+
+// Offer considers x and reports whether it was kept. Once the container is full, x replaces the
+// largest kept item only if it is strictly smaller.
+func (b *BoundedTopK[T]) Offer(x T) bool {
+	if b.k == 0 {
+		return false
+	}
+	seq := b.next
+	b.next++
+
+	if len(b.items) < b.k {
+		b.items = append(b.items, x)
+		b.seqs = append(b.seqs, seq)
+		b.up(len(b.items) - 1)
+		return true
+	}
+
+	// An equal item was necessarily offered earlier, so it stays.
+	if !b.less(x, b.items[0]) {
+		return false
+	}
+	b.items[0], b.seqs[0] = x, seq
+	b.down(0)
+	return true
+}
+
+// Len returns the number of items kept.
+func (b *BoundedTopK[T]) Len() int { return len(b.items) }
+
+// Full reports whether k items are kept, i.e. whether the next accepted Offer evicts one.
+func (b *BoundedTopK[T]) Full() bool { return len(b.items) == b.k }
+
+// Worst returns the largest kept item, the next one to be evicted.
+func (b *BoundedTopK[T]) Worst() (T, bool) {
+	if len(b.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return b.items[0], true
+}
+
+// Items returns the kept items in no particular order. The slice aliases the container's storage
+// and is only valid until the next Offer or Reset.
+func (b *BoundedTopK[T]) Items() []T { return b.items }
+
+// Reset empties the container, keeping its storage for reuse.
+func (b *BoundedTopK[T]) Reset() {
+	clear(b.items)
+	b.items = b.items[:0]
+	b.seqs = b.seqs[:0]
+	b.next = 0
+}
+
+// after reports whether the item at i ranks after the item at j.
+func (b *BoundedTopK[T]) after(i, j int) bool {
+	if b.less(b.items[j], b.items[i]) {
+		return true
+	}
+	if b.less(b.items[i], b.items[j]) {
+		return false
+	}
+	return b.seqs[i] > b.seqs[j]
+}
+
+func (b *BoundedTopK[T]) swap(i, j int) {
+	b.items[i], b.items[j] = b.items[j], b.items[i]
+	b.seqs[i], b.seqs[j] = b.seqs[j], b.seqs[i]
+}
+
+func (b *BoundedTopK[T]) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !b.after(i, parent) {
+			return
+		}
+		b.swap(i, parent)
+		i = parent
+	}
+}
+
+func (b *BoundedTopK[T]) down(i int) {
+	n := len(b.items)
+	for {
+		largest := i
+		if l := 2*i + 1; l < n && b.after(l, largest) {
+			largest = l
+		}
+		if r := 2*i + 2; r < n && b.after(r, largest) {
+			largest = r
+		}
+		if largest == i {
+			return
+		}
+		b.swap(i, largest)
+		i = largest
+	}
+}
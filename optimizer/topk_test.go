@@ -0,0 +1,63 @@
+package optimization
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBoundedTopKInts(t *testing.T) {
+	top := NewBoundedTopK(3, func(a, b int) bool { return a < b })
+	for _, v := range []int{9, 4, 7, 1, 8, 3, 3, 10} {
+		top.Offer(v)
+	}
+
+	got := slices.Sorted(slices.Values(top.Items()))
+	if !slices.Equal(got, []int{1, 3, 3}) {
+		t.Fatalf("BoundedTopK kept %v, expected [1 3 3]", got)
+	}
+	if worst, _ := top.Worst(); worst != 3 {
+		t.Fatalf("BoundedTopK worst is %v, expected 3", worst)
+	}
+}
+
+func TestBoundedTopKTies(t *testing.T) {
+	// Compare on length only: among equal lengths the first offered words must be kept.
+	byLength := func(a, b string) bool { return len(a) < len(b) }
+	top := NewBoundedTopK(3, byLength)
+	for _, w := range strings.Fields("ccc bb aa dd ee f gg") {
+		top.Offer(w)
+	}
+
+	got := slices.Sorted(slices.Values(top.Items()))
+	if !slices.Equal(got, []string{"aa", "bb", "f"}) {
+		t.Fatalf("BoundedTopK kept %v, expected [aa bb f]", got)
+	}
+}
+
+func TestBoundedTopKReset(t *testing.T) {
+	top := NewBoundedTopK(2, func(a, b float64) bool { return a < b })
+	top.Offer(5)
+	top.Offer(1)
+	top.Reset()
+	if top.Len() != 0 {
+		t.Fatalf("BoundedTopK has %d items after Reset", top.Len())
+	}
+	if _, ok := top.Worst(); ok {
+		t.Fatalf("BoundedTopK returned a worst item after Reset")
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		top.Reset()
+		for _, v := range []float64{4, 2, 8, 1, 9} {
+			top.Offer(v)
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("BoundedTopK allocated %v times per run, expected 0", allocs)
+	}
+
+	if empty := NewBoundedTopK(0, func(a, b int) bool { return a < b }); empty.Offer(1) {
+		t.Fatalf("BoundedTopK with k=0 accepted an item")
+	}
+}
@@ -0,0 +1,192 @@
+package optimization
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Tracer opens spans. It mirrors the shape of OpenTelemetry's tracer so an adapter is a few lines;
+// the library itself has no tracing dependency.
+type Tracer interface {
+	// Start opens a span named name as a child of whatever span ctx carries.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is an open unit of work.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	AddEvent(name string, attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Attribute is a key/value pair attached to a span or an event.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+type tracerKey struct{}
+
+// ContextWithTracer returns a copy of ctx carrying t, to be passed with WithContext.
+func ContextWithTracer(ctx context.Context, t Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, t)
+}
+
+// TracerFromContext returns the Tracer carried by ctx, or nil.
+func TracerFromContext(ctx context.Context) Tracer {
+	t, _ := ctx.Value(tracerKey{}).(Tracer)
+	return t
+}
+
+// startSpan opens a span for the named entry point when the configured context carries a tracer,
+// and routes the call's observer events to it. The span's context replaces the configured one, so
+// work done on behalf of the call is traced beneath it.
+func (o *options) startSpan(name string) {
+	if o.ctx == nil {
+		return
+	}
+	tracer := TracerFromContext(o.ctx)
+	if tracer == nil {
+		return
+	}
+	ctx, span := tracer.Start(o.ctx, name)
+	o.ctx = ctx
+	o.observer = MultiObserver(spanObserver{span}, o.observer)
+}
+
+// spanObserver turns observer events into span events and attributes, and ends the span with the call.
+type spanObserver struct {
+	span Span
+}
+
+func (s spanObserver) Observe(stats Stats) {
+	s.span.SetAttributes(statsAttributes(stats)...)
+	if stats.Err != nil {
+		s.span.RecordError(stats.Err)
+	}
+	s.span.End()
+}
+
+func (s spanObserver) OnStart(n int) {}
+
+func (s spanObserver) OnPhase(phase Phase, elapsed time.Duration) {
+	s.span.AddEvent(string(phase), Attribute{"elapsed_ns", elapsed.Nanoseconds()})
+}
+
+func (s spanObserver) OnProgress(phase Phase, done, total int) {
+	s.span.AddEvent("progress", Attribute{"phase", string(phase)}, Attribute{"done", done}, Attribute{"total", total})
+}
+
+func (s spanObserver) OnFinish(Stats) {}
+
+func statsAttributes(stats Stats) []Attribute {
+	attrs := []Attribute{
+		{"n", stats.N},
+		{"selected_count", stats.SelectedCount},
+		{"left_to_fill", stats.LeftToFill},
+		{"replacements", stats.Replacements},
+		{"negatives", stats.Negatives},
+		{"duration_ns", stats.Duration.Nanoseconds()},
+		{"path", string(stats.Path)},
+	}
+	if stats.Solver != "" {
+		attrs = append(attrs, Attribute{"solver", stats.Solver})
+	}
+	if stats.Switches > 0 || stats.SwitchesAvoided > 0 {
+		attrs = append(attrs, Attribute{"switches", stats.Switches}, Attribute{"switches_avoided", stats.SwitchesAvoided})
+	}
+	if stats.Err == nil {
+		attrs = append(attrs, Attribute{"cutoff_cost", stats.CutoffCost}, Attribute{"total_cost", stats.TotalCost})
+	}
+	return attrs
+}
+
+// RecordingTracer keeps every span in memory, for tests. It is safe for concurrent use.
+type RecordingTracer struct {
+	mu    sync.Mutex
+	spans []*RecordedSpan
+}
+
+// RecordedSpan is a span captured by RecordingTracer.
+type RecordedSpan struct {
+	Name       string
+	Parent     string // name of the parent span, empty for a root span
+	Attributes []Attribute
+	Events     []RecordedEvent
+	Errors     []error
+	Ended      bool
+
+	tracer *RecordingTracer
+}
+
+// RecordedEvent is a span event captured by RecordingTracer.
+type RecordedEvent struct {
+	Name       string
+	Attributes []Attribute
+}
+
+type recordedSpanKey struct{}
+
+func (t *RecordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &RecordedSpan{Name: name, tracer: t}
+	if parent, ok := ctx.Value(recordedSpanKey{}).(*RecordedSpan); ok {
+		span.Parent = parent.Name
+	}
+
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+
+	return context.WithValue(ctx, recordedSpanKey{}, span), span
+}
+
+// Spans returns a copy of the spans started so far, in start order.
+func (t *RecordingTracer) Spans() []RecordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	res := make([]RecordedSpan, len(t.spans))
+	for i, s := range t.spans {
+		res[i] = *s
+		res[i].Attributes = append([]Attribute(nil), s.Attributes...)
+		res[i].Events = append([]RecordedEvent(nil), s.Events...)
+		res[i].Errors = append([]error(nil), s.Errors...)
+		res[i].tracer = nil
+	}
+	return res
+}
+
+// Attribute returns the value of the last attribute set under key.
+func (s *RecordedSpan) Attribute(key string) (any, bool) {
+	for i := len(s.Attributes) - 1; i >= 0; i-- {
+		if s.Attributes[i].Key == key {
+			return s.Attributes[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+func (s *RecordedSpan) SetAttributes(attrs ...Attribute) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.Attributes = append(s.Attributes, attrs...)
+}
+
+func (s *RecordedSpan) AddEvent(name string, attrs ...Attribute) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.Events = append(s.Events, RecordedEvent{Name: name, Attributes: attrs})
+}
+
+func (s *RecordedSpan) RecordError(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.Errors = append(s.Errors, err)
+}
+
+func (s *RecordedSpan) End() {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.Ended = true
+}
@@ -0,0 +1,84 @@
+package optimization
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestTracingChildSpan(t *testing.T) {
+	tracer := &RecordingTracer{}
+	ctx, parent := tracer.Start(ContextWithTracer(context.Background(), tracer), "request")
+
+	var obs statsRecorder
+	if _, err := CostOptimization([]float64{3, 1, 2, 5}, WithContext(ctx), WithObserver(&obs)); err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+	parent.End()
+
+	spans := tracer.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, expected 2", len(spans))
+	}
+	span := spans[1]
+	if span.Name != "optimization.CostOptimization" || span.Parent != "request" || !span.Ended {
+		t.Fatalf("got span %q with parent %q (ended=%v)", span.Name, span.Parent, span.Ended)
+	}
+
+	var events []string
+	for _, e := range span.Events {
+		events = append(events, e.Name)
+	}
+	if len(events) != 4 || events[0] != "validate" || events[3] != "output" {
+		t.Fatalf("got events %v, expected the four phases", events)
+	}
+	if n, _ := span.Attribute("n"); n != 4 {
+		t.Fatalf("attribute n is %v, expected 4", n)
+	}
+	if total, _ := span.Attribute("total_cost"); total != 3.0 {
+		t.Fatalf("attribute total_cost is %v, expected 3", total)
+	}
+	if obs.calls != 1 {
+		t.Fatalf("the caller's observer was called %d times, expected 1", obs.calls)
+	}
+}
+
+func TestTracingRecordsErrors(t *testing.T) {
+	tracer := &RecordingTracer{}
+	ctx := ContextWithTracer(context.Background(), tracer)
+
+	CostOptimizationApprox([]float64{1, math.NaN()}, WithContext(ctx))
+
+	spans := tracer.Spans()
+	if len(spans) != 1 || spans[0].Name != "optimization.CostOptimizationApprox" {
+		t.Fatalf("got spans %+v", spans)
+	}
+	if len(spans[0].Errors) != 1 || spans[0].Errors[0] != ErrInvalidNumber || !spans[0].Ended {
+		t.Fatalf("got errors %v (ended=%v), expected %v", spans[0].Errors, spans[0].Ended, ErrInvalidNumber)
+	}
+}
+
+func TestTracingWithoutTracer(t *testing.T) {
+	if _, err := CostOptimization([]float64{1, 2}, WithContext(context.Background())); err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+}
+
+func TestTracingNestedRun(t *testing.T) {
+	tracer := &RecordingTracer{}
+	ctx := ContextWithTracer(context.Background(), tracer)
+
+	// The run without the switching penalty is done on behalf of the call and traced beneath it.
+	_, err := CostOptimization([]float64{1, 2, 3, 4}, WithContext(ctx), WithPreviousSelection([]int{0, 0, 1, 1}, 1))
+	if err != nil {
+		t.Fatalf("CostOptimization returned unexpected error: %v", err)
+	}
+
+	spans := tracer.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, expected 2", len(spans))
+	}
+	if spans[0].Parent != "" || spans[1].Parent != "optimization.CostOptimization" {
+		t.Fatalf("got parents %q and %q, expected the plain run beneath the call", spans[0].Parent, spans[1].Parent)
+	}
+}
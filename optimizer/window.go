@@ -0,0 +1,141 @@
+package optimization
+
+import "math"
+
+const (
+	PathWindow         Path = "window"          // contiguous block found with prefix sums
+	PathWindowCircular Path = "window_circular" // the best block wraps around the end of the input
+)
+
+// Window is a contiguous block of costs. With WithCircularWindow it may wrap around: it then
+// covers Start..n-1 followed by 0..Start+Length-n-1.
+type Window struct {
+	Start  int
+	Length int
+	Total  float64
+}
+
+// Selection returns the window as a binary slice of length n, like the output of CostOptimization.
+func (w Window) Selection(n int) []int {
+	res := make([]int, n)
+	for i := range w.Length {
+		res[(w.Start+i)%n] = 1
+	}
+	return res
+}
+
+// WithCircularWindow lets CostOptimizationWindow return windows that wrap around the end of the
+// input, for costs that repeat cyclically (hours of a day, days of a week).
+func WithCircularWindow() Option {
+	return func(opt *options) {
+		opt.circular = true
+	}
+}
+
+// CostOptimizationWindow returns the contiguous window of at least ⌈n/2⌉ costs with the smallest
+// total. Ties go to the lowest Start, then the shortest Length; a wrapping window is only chosen
+// when it is strictly cheaper. Both searches take O(n) time over prefix sums. Costs must be finite
+// (ErrInfiniteCost), since an infinite cost leaves no meaningful window total.
+func CostOptimizationWindow(prices []float64, opts ...Option) (_ Window, err error) {
+	cfg := applyOptions(opts)
+	track := cfg.beginCall("optimization.CostOptimizationWindow", len(prices))
+	stats := Stats{N: len(prices)}
+	defer track.finish(&stats, &err)
+
+	if err = cfg.supports(featureCircular); err != nil {
+		return Window{}, err
+	}
+
+	if len(prices) == 0 {
+		return Window{}, ErrEmptyInput
+	}
+
+	// prefix[i] is the sum of the first i costs.
+	prefix := make([]float64, len(prices)+1)
+	for i, value := range prices {
+		if math.IsNaN(value) {
+			stats.ValidateDuration = track.lap(PhaseValidate)
+			return Window{}, ErrInvalidNumber
+		}
+		if math.IsInf(value, 0) {
+			stats.ValidateDuration = track.lap(PhaseValidate)
+			return Window{}, ErrInfiniteCost
+		}
+		if i > 0 && i%progressInterval == 0 {
+			track.progress(PhaseValidate, i, len(prices))
+		}
+		if value < 0 {
+			stats.Negatives++
+		}
+		prefix[i+1] = prefix[i] + value
+	}
+	stats.ValidateDuration = track.lap(PhaseValidate)
+
+	minSize := requiredCount(len(prices))
+	w := bestWindow(prefix, minSize)
+	stats.Path = PathWindow
+	if cfg.circular && minSize < len(prices) {
+		if c, ok := bestWrappingWindow(prefix, minSize); ok && c.Total < w.Total {
+			w = c
+			stats.Path = PathWindowCircular
+		}
+	}
+	stats.FillDuration = track.lap(PhaseFill)
+
+	// Sum the chosen window directly rather than trusting the difference of two large prefix sums.
+	w.Total = 0
+	for i := range w.Length {
+		w.Total += prices[(w.Start+i)%len(prices)]
+	}
+	stats.SelectedCount = w.Length
+	stats.TotalCost = w.Total
+	stats.OutputDuration = track.lap(PhaseOutput)
+
+	return w, nil
+}
+
+// bestWindow returns the cheapest non-wrapping window of at least minSize costs. The start is the
+// earliest index with the largest prefix sum allowed so far; that index never moves backwards, so
+// keeping the first strictly cheaper window yields the lowest start, then the shortest length.
+func bestWindow(prefix []float64, minSize int) Window {
+	n := len(prefix) - 1
+	best := Window{Total: math.Inf(1)}
+	from := 0
+	for end := minSize; end <= n; end++ {
+		if i := end - minSize; prefix[i] > prefix[from] {
+			from = i
+		}
+		if total := prefix[end] - prefix[from]; total < best.Total {
+			best = Window{Start: from, Length: end - from, Total: total}
+		}
+	}
+	return best
+}
+
+// bestWrappingWindow returns the cheapest window wrapping around the end. Such a window is the whole
+// input minus a non-wrapping gap of at most n-minSize costs, so it is found by maximizing the gap
+// sum prefix[j]-prefix[i] over j-maxGap <= i < j, with a deque holding increasing prefix minima.
+func bestWrappingWindow(prefix []float64, minSize int) (Window, bool) {
+	n := len(prefix) - 1
+	maxGap := n - minSize
+	total := prefix[n]
+
+	best, found := Window{Total: math.Inf(1)}, false
+	deque := make([]int, 0, maxGap+1)
+	// A gap starting at 0 or ending at n leaves a non-wrapping window, already covered.
+	for j := 2; j < n; j++ {
+		i := j - 1
+		for len(deque) > 0 && prefix[deque[len(deque)-1]] >= prefix[i] {
+			deque = deque[:len(deque)-1]
+		}
+		deque = append(deque, i)
+		if deque[0] < j-maxGap {
+			deque = deque[1:]
+		}
+		if gap := prefix[j] - prefix[deque[0]]; total-gap < best.Total {
+			best = Window{Start: j, Length: n - (j - deque[0]), Total: total - gap}
+			found = true
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,126 @@
+package optimization
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestCostOptimizationWindow(t *testing.T) {
+	costs := []float64{4, -1, 2, -3, 5, 0, 1}
+	stats := &statsRecorder{}
+
+	w, err := CostOptimizationWindow(costs, WithObserver(stats))
+	if err != nil {
+		t.Fatalf("CostOptimizationWindow returned unexpected error: %v", err)
+	}
+	// At least 4 costs: 4 -1 2 -3 is the cheapest block.
+	if expected := (Window{Start: 0, Length: 4, Total: 2}); w != expected {
+		t.Fatalf("window %+v, expected %+v", w, expected)
+	}
+	if !slices.Equal(w.Selection(len(costs)), []int{1, 1, 1, 1, 0, 0, 0}) {
+		t.Fatalf("selection %v", w.Selection(len(costs)))
+	}
+	if stats.last.Path != PathWindow || stats.last.SelectedCount != 4 || stats.last.TotalCost != 2 || stats.last.Negatives != 2 {
+		t.Fatalf("unexpected stats %+v", stats.last)
+	}
+
+	// The cheap costs sit at both ends; only a wrapping window can take them together.
+	costs = []float64{-2, 9, 9, 9, 0, -1}
+	w, _ = CostOptimizationWindow(costs)
+	if expected := (Window{Start: 3, Length: 3, Total: 8}); w != expected {
+		t.Fatalf("linear window %+v, expected %+v", w, expected)
+	}
+	w, _ = CostOptimizationWindow(costs, WithCircularWindow(), WithObserver(stats))
+	if expected := (Window{Start: 4, Length: 3, Total: -3}); w != expected {
+		t.Fatalf("circular window %+v, expected %+v", w, expected)
+	}
+	if !slices.Equal(w.Selection(len(costs)), []int{1, 0, 0, 0, 1, 1}) || stats.last.Path != PathWindowCircular {
+		t.Fatalf("selection %v, path %s", w.Selection(len(costs)), stats.last.Path)
+	}
+}
+
+func TestCostOptimizationWindowTies(t *testing.T) {
+	w, _ := CostOptimizationWindow([]float64{0, 0, 0, 0, 0})
+	if expected := (Window{Start: 0, Length: 3}); w != expected {
+		t.Fatalf("window %+v, expected %+v", w, expected)
+	}
+	w, _ = CostOptimizationWindow([]float64{7})
+	if expected := (Window{Start: 0, Length: 1, Total: 7}); w != expected {
+		t.Fatalf("window %+v, expected %+v", w, expected)
+	}
+}
+
+func TestCostOptimizationWindowIsOptimal(t *testing.T) {
+	r := rand.New(rand.NewPCG(48, 1))
+	for range 500 {
+		costs := make([]float64, 1+r.IntN(12))
+		for i := range costs {
+			costs[i] = float64(r.IntN(21) - 10)
+		}
+		for _, circular := range []bool{false, true} {
+			opts := []Option{}
+			if circular {
+				opts = append(opts, WithCircularWindow())
+			}
+			w, err := CostOptimizationWindow(costs, opts...)
+			if err != nil {
+				t.Fatalf("CostOptimizationWindow returned unexpected error: %v", err)
+			}
+			sel := w.Selection(len(costs))
+			if total, _ := TotalCost(costs, sel); total != w.Total || w.Length < requiredCount(len(costs)) {
+				t.Fatalf("costs %v: window %+v has total %v", costs, w, total)
+			}
+			if !circular && w.Start+w.Length > len(costs) {
+				t.Fatalf("costs %v: linear window %+v wraps", costs, w)
+			}
+			if best := bruteForceWindow(costs, circular); w.Total != best {
+				t.Fatalf("costs %v circular=%v: total %v, optimum %v", costs, circular, w.Total, best)
+			}
+		}
+	}
+}
+
+func TestCostOptimizationWindowErrors(t *testing.T) {
+	cases := []struct {
+		costs []float64
+		err   error
+	}{
+		{nil, ErrEmptyInput},
+		{[]float64{1, math.NaN()}, ErrInvalidNumber},
+		{[]float64{1, math.Inf(-1)}, ErrInfiniteCost},
+	}
+	for _, c := range cases {
+		if _, err := CostOptimizationWindow(c.costs); !errors.Is(err, c.err) {
+			t.Errorf("costs %v: expected %v, got %v", c.costs, c.err, err)
+		}
+	}
+}
+
+func BenchmarkCostOptimizationWindow(b *testing.B) {
+	costs := randFloats(-100, 500, 1_000_000)
+	for b.Loop() {
+		CostOptimizationWindow(costs, WithCircularWindow())
+	}
+}
+
+// bruteForceWindow returns the smallest total over every window of at least ⌈n/2⌉ costs.
+func bruteForceWindow(costs []float64, circular bool) float64 {
+	n := len(costs)
+	best := math.Inf(1)
+	for start := range n {
+		for length := requiredCount(n); length <= n; length++ {
+			if !circular && start+length > n {
+				break
+			}
+			total := 0.0
+			for i := range length {
+				total += costs[(start+i)%n]
+			}
+			best = min(best, total)
+		}
+	}
+	return best
+}